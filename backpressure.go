@@ -0,0 +1,59 @@
+package go_fast_cache
+
+import (
+	"context"
+	"github.com/daqnext/go-fast-cache/sortedset"
+	"time"
+)
+
+// BackpressurePolicy is the policy of writes when the queue of the expiry index is full, see SetBackpressure
+type BackpressurePolicy = sortedset.Backpressure
+
+const (
+	// BackpressureBlock waits until the queue has room or the context is done, it is the default
+	BackpressureBlock = sortedset.BackpressureBlock
+	// BackpressureTimeout waits at most the timeout, or until the context is done
+	BackpressureTimeout = sortedset.BackpressureTimeout
+	// BackpressureDrop rejects the write at once and counts it
+	BackpressureDrop = sortedset.BackpressureDrop
+	// BackpressureSync applies the queued index jobs and the write in the caller
+	BackpressureSync = sortedset.BackpressureSync
+)
+
+// ErrBackpressure is returned by SetContext when the write is rejected because the queue is full
+var ErrBackpressure = sortedset.ErrQueueFull
+
+// BackpressureStats counts the writes which found the queue full
+type BackpressureStats = sortedset.BackpressureStats
+
+// SetBackpressure sets the policy of writes when the queue of the expiry index is full, timeout is used by BackpressureTimeout.
+// Rejected writes leave the cache unchanged, Set drops them silently and SetContext returns the error. Deletes are never rejected
+func (lc *LocalCache) SetBackpressure(policy BackpressurePolicy, timeout time.Duration) {
+	lc.s.SetBackpressure(policy, timeout)
+}
+
+// GetBackpressureStats returns the counts of writes which found the queue full
+func (lc *LocalCache) GetBackpressureStats() BackpressureStats {
+	return lc.s.GetBackpressureStats()
+}
+
+// SetContext Set key value with expire time like Set, returns ErrBackpressure or the context error if the cache cannot accept the write in time
+func (lc *LocalCache) SetContext(ctx context.Context, key string, value interface{}, ttlSecond int64) error {
+	err := lc.handler().Set(ctx, key, value, ttlSecond)
+	for _, h := range lc.getHooks() {
+		h.OnSet(ctx, key, err)
+	}
+	return err
+}
+
+// setContext sets key value without the middleware chain
+func (lc *LocalCache) setContext(ctx context.Context, key string, value interface{}, ttlSecond int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	e, ok := lc.element(key, value, ttlSecond)
+	if !ok {
+		return nil
+	}
+	return lc.add(ctx, key, e)
+}
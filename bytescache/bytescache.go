@@ -0,0 +1,125 @@
+package bytescache
+
+import (
+	"errors"
+	"github.com/daqnext/go-fast-cache/logging"
+	"github.com/daqnext/go-fast-cache/supervisor"
+	"math"
+	"time"
+)
+
+const (
+	ShardCount = 256
+
+	MaxTTLSecond                      = 7200
+	MinCapacityMB                     = 1
+	DefaultDeleteExpireIntervalSecond = 5
+)
+
+var ErrEntryTooLarge = errors.New("entry is larger than a shard")
+
+// BytesCache stores []byte values in preallocated arenas indexed by hashed key, so the heap contains few pointers regardless of the key count.
+// When an arena is full the oldest entries of it are overwritten
+type BytesCache struct {
+	shards []*shard
+	llog   logging.Logger
+	sup    *supervisor.Supervisor
+}
+
+// New Instance of BytesCache with capacityMB megabytes of arenas, if logger is nil nothing is logged
+func New(capacityMB int, logger logging.Logger) *BytesCache {
+	if capacityMB < MinCapacityMB {
+		capacityMB = MinCapacityMB
+	}
+	shardSize := capacityMB * 1024 * 1024 / ShardCount
+	c := &BytesCache{
+		shards: make([]*shard, ShardCount),
+		llog:   logging.OrNop(logger),
+		sup:    supervisor.New(logger),
+	}
+	for i := range c.shards {
+		c.shards[i] = newShard(shardSize)
+	}
+	c.scheduleDeleteExpire(DefaultDeleteExpireIntervalSecond)
+	return c
+}
+
+// Set Set key value with expire time in second, value is copied into the arena
+func (c *BytesCache) Set(key string, value []byte, ttlSecond int64) error {
+	if ttlSecond <= 0 {
+		return nil
+	}
+	if ttlSecond > MaxTTLSecond {
+		ttlSecond = MaxTTLSecond
+	}
+	s := c.shard(key)
+	if len(key) > math.MaxUint16 || headerSize+len(key)+len(value) > len(s.arena) {
+		return ErrEntryTooLarge
+	}
+	s.set(hashKey(key), key, value, time.Now().Unix()+ttlSecond)
+	return nil
+}
+
+// Get returns a copy of the value and the ttl left
+func (c *BytesCache) Get(key string) (value []byte, ttl int64, exist bool) {
+	now := time.Now().Unix()
+	value, expireAt, exist := c.shard(key).get(hashKey(key), key, now)
+	if !exist {
+		return nil, 0, false
+	}
+	return value, expireAt - now, true
+}
+
+func (c *BytesCache) Delete(key string) {
+	c.shard(key).delete(hashKey(key), key)
+}
+
+// GetLen returns the count of keys, expired keys not yet removed are included
+func (c *BytesCache) GetLen() int64 {
+	count := int64(0)
+	for _, s := range c.shards {
+		count += int64(s.len())
+	}
+	return count
+}
+
+// SetOnError registers f which is called with the job name and the error when the janitor panics, f must not block
+func (c *BytesCache) SetOnError(f func(job string, err error)) {
+	c.sup.SetOnError(f)
+}
+
+// SetRestartBackoff sets the min and max delay before restarting a panicked janitor
+func (c *BytesCache) SetRestartBackoff(min time.Duration, max time.Duration) {
+	c.sup.SetBackoff(min, max)
+}
+
+// Health reports whether the janitor is alive
+func (c *BytesCache) Health() supervisor.Health {
+	return c.sup.Health()
+}
+
+func (c *BytesCache) shard(key string) *shard {
+	return c.shards[hashKey(key)%ShardCount]
+}
+
+func (c *BytesCache) scheduleDeleteExpire(intervalSecond int) {
+	c.sup.Go("bytescache.scheduleDeleteExpire", func() {
+		for {
+			time.Sleep(time.Duration(intervalSecond) * time.Second)
+			now := time.Now().Unix()
+			for _, s := range c.shards {
+				s.removeExpired(now)
+			}
+		}
+	})
+}
+
+// hashKey is fnv-1a 64
+func hashKey(key string) uint64 {
+	hash := uint64(14695981039346656037)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint64(key[i])
+		hash *= 1099511628211
+	}
+	return hash
+}
@@ -0,0 +1,148 @@
+package bytescache
+
+import (
+	"encoding/binary"
+	"sync"
+)
+
+const (
+	// expireAt(8) hash(8) keyLen(2) valueLen(4)
+	headerSize = 22
+)
+
+// shard stores entries in a preallocated ring arena, the oldest entries are overwritten when the arena is full.
+// index has no pointers, so the gc does not scan it
+type shard struct {
+	lock  sync.RWMutex
+	index map[uint64]uint32 // key hash => entry offset in arena
+	arena []byte
+
+	// live entries are in [head, tail), or [head, wrapAt) and [0, tail) after the tail wrapped
+	head   int
+	tail   int
+	wrapAt int
+}
+
+func newShard(size int) *shard {
+	return &shard{
+		index:  make(map[uint64]uint32),
+		arena:  make([]byte, size),
+		wrapAt: -1,
+	}
+}
+
+func (s *shard) set(hash uint64, key string, value []byte, expireAt int64) {
+	size := headerSize + len(key) + len(value)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	offset := s.reserve(size)
+	entry := s.arena[offset : offset+size]
+	binary.LittleEndian.PutUint64(entry[0:8], uint64(expireAt))
+	binary.LittleEndian.PutUint64(entry[8:16], hash)
+	binary.LittleEndian.PutUint16(entry[16:18], uint16(len(key)))
+	binary.LittleEndian.PutUint32(entry[18:22], uint32(len(value)))
+	copy(entry[headerSize:], key)
+	copy(entry[headerSize+len(key):], value)
+	s.tail = offset + size
+	s.index[hash] = uint32(offset)
+}
+
+// reserve evicts the oldest entries until size bytes are free at the tail, returns the offset to write
+func (s *shard) reserve(size int) int {
+	for {
+		if s.wrapAt < 0 {
+			if s.tail+size <= len(s.arena) {
+				return s.tail
+			}
+			if s.head == s.tail {
+				//empty
+				s.head, s.tail = 0, 0
+				continue
+			}
+			s.wrapAt = s.tail
+			s.tail = 0
+			continue
+		}
+		if s.tail+size <= s.head {
+			return s.tail
+		}
+		s.evictHead()
+	}
+}
+
+// evictHead removes the oldest entry, only called after the tail wrapped
+func (s *shard) evictHead() {
+	hash, _, size := s.header(s.head)
+	if offset, exist := s.index[hash]; exist && int(offset) == s.head {
+		delete(s.index, hash)
+	}
+	s.head += size
+	if s.head >= s.wrapAt {
+		s.head = 0
+		s.wrapAt = -1
+	}
+}
+
+// header returns hash, expireAt and the total size of the entry at offset
+func (s *shard) header(offset int) (hash uint64, expireAt int64, size int) {
+	h := s.arena[offset : offset+headerSize]
+	expireAt = int64(binary.LittleEndian.Uint64(h[0:8]))
+	hash = binary.LittleEndian.Uint64(h[8:16])
+	size = headerSize + int(binary.LittleEndian.Uint16(h[16:18])) + int(binary.LittleEndian.Uint32(h[18:22]))
+	return hash, expireAt, size
+}
+
+// get returns a copy of the value
+func (s *shard) get(hash uint64, key string, now int64) (value []byte, expireAt int64, exist bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	offset, exist := s.index[hash]
+	if !exist {
+		return nil, 0, false
+	}
+	_, expireAt, size := s.header(int(offset))
+	if expireAt <= now {
+		return nil, 0, false
+	}
+	entry := s.arena[int(offset) : int(offset)+size]
+	keyLen := int(binary.LittleEndian.Uint16(entry[16:18]))
+	//hash collision
+	if string(entry[headerSize:headerSize+keyLen]) != key {
+		return nil, 0, false
+	}
+	value = make([]byte, size-headerSize-keyLen)
+	copy(value, entry[headerSize+keyLen:])
+	return value, expireAt, true
+}
+
+func (s *shard) delete(hash uint64, key string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	offset, exist := s.index[hash]
+	if !exist {
+		return
+	}
+	entry := s.arena[offset:]
+	keyLen := int(binary.LittleEndian.Uint16(entry[16:18]))
+	if string(entry[headerSize:headerSize+keyLen]) == key {
+		delete(s.index, hash)
+	}
+}
+
+// removeExpired removes expired entries from the index, their space is reused when the tail passes
+func (s *shard) removeExpired(now int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for hash, offset := range s.index {
+		if _, expireAt, _ := s.header(int(offset)); expireAt <= now {
+			delete(s.index, hash)
+		}
+	}
+}
+
+func (s *shard) len() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return len(s.index)
+}
@@ -0,0 +1,141 @@
+package cachetest
+
+import (
+	localcache "github.com/daqnext/go-fast-cache"
+	"github.com/daqnext/go-fast-cache/ttltype"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Factory returns a new empty Cache for one test, and a function moving its clock forward: time.Sleep for real caches, Fake.Advance for the fake
+type Factory func(t *testing.T) (c localcache.Cache, advance func(d time.Duration))
+
+// Run runs the conformance suite against the caches made by factory, every case is a subtest on a new cache
+func Run(t *testing.T, factory Factory) {
+	cases := []struct {
+		name string
+		f    func(t *testing.T, c localcache.Cache, advance func(d time.Duration))
+	}{
+		{"SetGet", testSetGet},
+		{"Overwrite", testOverwrite},
+		{"InvalidTTL", testInvalidTTL},
+		{"KeepTTL", testKeepTTL},
+		{"Expire", testExpire},
+		{"Delete", testDelete},
+		{"GetLen", testGetLen},
+		{"Rand", testRand},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c, advance := factory(t)
+			tc.f(t, c, advance)
+		})
+	}
+}
+
+func testSetGet(t *testing.T, c localcache.Cache, advance func(d time.Duration)) {
+	c.Set("a", 1, 10)
+	value, ttl, exist := c.Get("a")
+	if !exist || value != 1 {
+		t.Fatalf("Get a = %v, %v", value, exist)
+	}
+	if ttl <= 0 || ttl > 10 {
+		t.Fatalf("ttl %d not in (0, 10]", ttl)
+	}
+	if _, _, exist := c.Get("missing"); exist {
+		t.Fatal("Get of a missing key exists")
+	}
+}
+
+func testOverwrite(t *testing.T, c localcache.Cache, advance func(d time.Duration)) {
+	c.Set("a", 1, 10)
+	c.Set("a", 2, 20)
+	value, ttl, exist := c.Get("a")
+	if !exist || value != 2 {
+		t.Fatalf("Get a = %v, %v", value, exist)
+	}
+	if ttl <= 10 || ttl > 20 {
+		t.Fatalf("ttl %d not in (10, 20]", ttl)
+	}
+}
+
+func testInvalidTTL(t *testing.T, c localcache.Cache, advance func(d time.Duration)) {
+	c.Set("negative", 1, -5)
+	if _, _, exist := c.Get("negative"); exist {
+		t.Fatal("negative ttl is stored")
+	}
+	c.Set("max", 1, localcache.MaxTTLSecond*10)
+	if _, ttl, _ := c.Get("max"); ttl <= 0 || ttl > localcache.MaxTTLSecond {
+		t.Fatalf("ttl %d not in (0, MaxTTLSecond]", ttl)
+	}
+}
+
+func testKeepTTL(t *testing.T, c localcache.Cache, advance func(d time.Duration)) {
+	c.Set("new", 1, ttltype.Keep)
+	if _, ttl, exist := c.Get("new"); !exist || ttl <= 0 || ttl > 30 {
+		t.Fatalf("new key with Keep ttl %d, %v", ttl, exist)
+	}
+
+	c.Set("a", 1, 100)
+	advance(1100 * time.Millisecond)
+	c.Set("a", 2, ttltype.Keep)
+	value, ttl, exist := c.Get("a")
+	if !exist || value != 2 {
+		t.Fatalf("Get a = %v, %v", value, exist)
+	}
+	if ttl <= 0 || ttl >= 100 {
+		t.Fatalf("kept ttl %d not in (0, 100)", ttl)
+	}
+}
+
+func testExpire(t *testing.T, c localcache.Cache, advance func(d time.Duration)) {
+	c.Set("a", 1, 1)
+	c.Set("b", 1, 60)
+	advance(2100 * time.Millisecond)
+	if _, _, exist := c.Get("a"); exist {
+		t.Fatal("expired key exists")
+	}
+	if _, _, exist := c.Get("b"); !exist {
+		t.Fatal("unexpired key missing")
+	}
+}
+
+func testDelete(t *testing.T, c localcache.Cache, advance func(d time.Duration)) {
+	c.Set("a", 1, 10)
+	c.Delete("a")
+	if _, _, exist := c.Get("a"); exist {
+		t.Fatal("deleted key exists")
+	}
+	c.Delete("missing")
+	if n := c.GetLen(); n != 0 {
+		t.Fatalf("GetLen %d after delete", n)
+	}
+}
+
+func testGetLen(t *testing.T, c localcache.Cache, advance func(d time.Duration)) {
+	for i := 0; i < 3; i++ {
+		c.Set(strconv.Itoa(i), i, 10)
+	}
+	c.Set("0", 0, 10)
+	if n := c.GetLen(); n != 3 {
+		t.Fatalf("GetLen %d, want 3", n)
+	}
+}
+
+func testRand(t *testing.T, c localcache.Cache, advance func(d time.Duration)) {
+	token := c.SetRand("r", 10)
+	if token == "" {
+		t.Fatal("empty token")
+	}
+	if got := c.GetRand("r"); got != token {
+		t.Fatalf("GetRand %q, want %q", got, token)
+	}
+	if other := c.SetRand("s", 10); other == token {
+		t.Fatalf("same token %q twice", token)
+	}
+	if got := c.GetRand("missing"); got != "" {
+		t.Fatalf("GetRand of a missing key %q", got)
+	}
+}
@@ -0,0 +1,144 @@
+package cachetest
+
+import (
+	localcache "github.com/daqnext/go-fast-cache"
+	"github.com/daqnext/go-fast-cache/ttltype"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// DefaultSeed of the tokens made by Fake.SetRand
+const DefaultSeed = 1
+
+const letterBytes = "abcdefghijklmnopqrstuvwxyz"
+
+type item struct {
+	value    interface{}
+	expireAt int64
+}
+
+// Fake is a deterministic in-memory localcache.Cache for tests: it has no goroutines, its clock only moves on Advance, and SetRand makes the same tokens for the same seed.
+// It follows the ttl rules of LocalCache without jitter, expired keys are removed when read
+type Fake struct {
+	lock  sync.Mutex
+	clock time.Duration // since Unix time 0
+	items map[string]item
+	rand  *rand.Rand
+}
+
+var _ localcache.Cache = (*Fake)(nil)
+
+// NewFake returns an empty Fake with the clock at Unix time 0 and DefaultSeed
+func NewFake() *Fake {
+	return NewFakeWithSeed(DefaultSeed)
+}
+
+// NewFakeWithSeed returns an empty Fake which SetRand tokens are made from seed
+func NewFakeWithSeed(seed int64) *Fake {
+	return &Fake{
+		items: make(map[string]item),
+		rand:  rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Advance moves the clock forward by d, ttls count the whole seconds passed like LocalCache
+func (f *Fake) Advance(d time.Duration) {
+	f.lock.Lock()
+	f.clock += d
+	f.lock.Unlock()
+}
+
+// Now returns the time of the clock
+func (f *Fake) Now() time.Time {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return time.Unix(0, int64(f.clock))
+}
+
+// now returns the Unix second of the clock, the caller must hold the lock
+func (f *Fake) now() int64 {
+	return int64(f.clock / time.Second)
+}
+
+func (f *Fake) Get(key string) (value interface{}, ttl int64, exist bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	it, exist := f.get(key)
+	if !exist {
+		return nil, 0, false
+	}
+	return it.value, it.expireAt - f.now(), true
+}
+
+// get returns the unexpired item of key, the caller must hold the lock
+func (f *Fake) get(key string) (item, bool) {
+	it, exist := f.items[key]
+	if !exist {
+		return item{}, false
+	}
+	if it.expireAt <= f.now() {
+		delete(f.items, key)
+		return item{}, false
+	}
+	return it, true
+}
+
+func (f *Fake) Set(key string, value interface{}, ttlSecond int64) {
+	if ttlSecond < 0 {
+		return
+	}
+	if ttlSecond > localcache.MaxTTLSecond {
+		ttlSecond = localcache.MaxTTLSecond
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if ttlSecond == ttltype.Keep {
+		if old, exist := f.get(key); exist {
+			f.items[key] = item{value: value, expireAt: old.expireAt}
+			return
+		}
+		ttlSecond = 30
+	}
+	f.items[key] = item{value: value, expireAt: f.now() + ttlSecond}
+}
+
+func (f *Fake) Delete(key string) {
+	f.lock.Lock()
+	delete(f.items, key)
+	f.lock.Unlock()
+}
+
+// GetLen returns the count of unexpired keys
+func (f *Fake) GetLen() int64 {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	count := int64(0)
+	for _, it := range f.items {
+		if it.expireAt > f.now() {
+			count++
+		}
+	}
+	return count
+}
+
+func (f *Fake) SetRand(key string, ttlSecond int64) string {
+	f.lock.Lock()
+	b := make([]byte, 20)
+	for i := range b {
+		b[i] = letterBytes[f.rand.Intn(len(letterBytes))]
+	}
+	f.lock.Unlock()
+	rs := string(b)
+	f.Set(key, rs, ttlSecond)
+	return rs
+}
+
+func (f *Fake) GetRand(key string) string {
+	v, _, exist := f.Get(key)
+	if !exist {
+		return ""
+	}
+	s, _ := v.(string)
+	return s
+}
@@ -0,0 +1,25 @@
+module github.com/daqnext/go-fast-cache/cmd/fastcache-server
+
+go 1.20
+
+require (
+	github.com/daqnext/LocalLog v0.2.4
+	github.com/daqnext/go-fast-cache v0.0.0-00010101000000-000000000000
+	github.com/daqnext/go-fast-cache/logging/locallogadapter v0.0.0-00010101000000-000000000000
+)
+
+require (
+	github.com/antonfisher/nested-logrus-formatter v1.3.1 // indirect
+	github.com/daqnext/fastjson v1.0.0 // indirect
+	github.com/daqnext/jsonparser v1.1.2 // indirect
+	github.com/daqnext/utils v0.0.6 // indirect
+	github.com/natefinch/lumberjack v2.0.0+incompatible // indirect
+	github.com/sirupsen/logrus v1.8.1 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+)
+
+// the replace builds against the local go-fast-cache, a release requires the tagged version, see README nested modules
+replace (
+	github.com/daqnext/go-fast-cache => ../..
+	github.com/daqnext/go-fast-cache/logging/locallogadapter => ../../logging/locallogadapter
+)
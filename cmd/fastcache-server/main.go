@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	locallog "github.com/daqnext/LocalLog/log"
+	localcache "github.com/daqnext/go-fast-cache"
+	"github.com/daqnext/go-fast-cache/logging/locallogadapter"
+	"github.com/daqnext/go-fast-cache/resp"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:6380", "listen address")
+	interval := flag.Int("interval", localcache.DefaultDeleteExpireIntervalSecond, "interval seconds of the delete expired keys job")
+	limit := flag.Int64("limit", localcache.DefaultCountLimit, "max key count")
+	logs := flag.String("logs", "logs", "log folder")
+	flag.Parse()
+
+	log, err := locallog.New(*logs, 2, 20, 30)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	logger := locallogadapter.New(log)
+	lc := localcache.NewWithInterval(*interval, logger)
+	lc.SetCountLimit(*limit)
+
+	log.Println("fastcache-server listening on", *addr)
+	if err := resp.NewServer(lc, logger).ListenAndServe(*addr); err != nil {
+		panic(err.Error())
+	}
+}
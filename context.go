@@ -0,0 +1,51 @@
+package go_fast_cache
+
+import (
+	"context"
+	"time"
+)
+
+// Hook observes cache operations. ctx is the one given to the Ctx variants, context.Background() for the plain methods, so hooks can read trace information from it.
+// Hooks are called synchronously and must not block
+type Hook interface {
+	// OnGet is called by Get and GetOrLoad with whether the key was found, with the key given to them before the middlewares
+	OnGet(ctx context.Context, key string, hit bool)
+	// OnSet is called by Set, SetWithTags and SetSliding with the error returned by SetContext, values stored by GetOrLoad are reported by OnLoad only
+	OnSet(ctx context.Context, key string, err error)
+	// OnLoad is called by GetOrLoad after the loader returned
+	OnLoad(ctx context.Context, key string, duration time.Duration, err error)
+}
+
+// AddHook registers h, hooks are called in the order added
+func (lc *LocalCache) AddHook(h Hook) {
+	lc.hookLock.Lock()
+	defer lc.hookLock.Unlock()
+	old := lc.getHooks()
+	hooks := make([]Hook, len(old), len(old)+1)
+	copy(hooks, old)
+	lc.hooks.Store(append(hooks, h))
+}
+
+func (lc *LocalCache) getHooks() []Hook {
+	hooks, _ := lc.hooks.Load().([]Hook)
+	return hooks
+}
+
+// GetCtx is Get passing ctx to the middlewares and the hooks, Get never blocks so ctx is not checked for cancellation
+func (lc *LocalCache) GetCtx(ctx context.Context, key string) (value interface{}, ttl int64, exist bool) {
+	value, ttl, exist = lc.handler().Get(ctx, key)
+	for _, h := range lc.getHooks() {
+		h.OnGet(ctx, key, exist)
+	}
+	return value, ttl, exist
+}
+
+// SetCtx is SetContext, named like GetCtx and GetOrLoadCtx
+func (lc *LocalCache) SetCtx(ctx context.Context, key string, value interface{}, ttlSecond int64) error {
+	return lc.SetContext(ctx, key, value, ttlSecond)
+}
+
+// DeleteCtx is Delete passing ctx to the middlewares
+func (lc *LocalCache) DeleteCtx(ctx context.Context, key string) {
+	lc.handler().Delete(ctx, key)
+}
@@ -0,0 +1,275 @@
+package go_fast_cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrWrongType is returned when a hash, list or set operation is used on a key holding another kind of value
+var ErrWrongType = errors.New("operation against a key holding the wrong kind of value")
+
+// ErrInvalidTTL is returned by the hash, list and set writes for a negative ttl
+var ErrInvalidTTL = errors.New("ttl must be ttltype.Keep or positive")
+
+type hashValue struct {
+	lock   sync.RWMutex
+	fields map[string]interface{}
+}
+
+// listValue items are stored from tail to head, so LPush appends and RPop takes the first item
+type listValue struct {
+	lock  sync.RWMutex
+	items []interface{}
+}
+
+type setValue struct {
+	lock    sync.RWMutex
+	members map[string]struct{}
+}
+
+// getValue returns the value of key if is reports it is of the wanted kind, nil if key not exist. If create is not nil, a key not exist is stored with the value made by create,
+// and the ttl of the key is refreshed with ttlSecond, ttltype.Keep keeps the ttl left. A key holding another kind of value is left unchanged.
+// Data type values bypass the middleware chain and the hooks, which work on plain values
+func (lc *LocalCache) getValue(key string, ttlSecond int64, create func() interface{}, is func(value interface{}) bool) (interface{}, error) {
+	if create == nil {
+		value, _, exist := lc.get(key)
+		if !exist {
+			return nil, nil
+		}
+		if !is(value) {
+			return nil, ErrWrongType
+		}
+		return value, nil
+	}
+	if ttlSecond < 0 {
+		return nil, ErrInvalidTTL
+	}
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+	value, _, exist := lc.get(key)
+	if exist && !is(value) {
+		return nil, ErrWrongType
+	}
+	if !exist {
+		value = create()
+	}
+	if err := lc.setContext(context.Background(), key, value, ttlSecond); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (lc *LocalCache) getHash(key string, ttlSecond int64, create bool) (*hashValue, error) {
+	var newHash func() interface{}
+	if create {
+		newHash = func() interface{} {
+			return &hashValue{fields: make(map[string]interface{})}
+		}
+	}
+	value, err := lc.getValue(key, ttlSecond, newHash, func(value interface{}) bool {
+		_, ok := value.(*hashValue)
+		return ok
+	})
+	if value == nil {
+		return nil, err
+	}
+	return value.(*hashValue), nil
+}
+
+func (lc *LocalCache) getList(key string, ttlSecond int64, create bool) (*listValue, error) {
+	var newList func() interface{}
+	if create {
+		newList = func() interface{} {
+			return &listValue{items: make([]interface{}, 0)}
+		}
+	}
+	value, err := lc.getValue(key, ttlSecond, newList, func(value interface{}) bool {
+		_, ok := value.(*listValue)
+		return ok
+	})
+	if value == nil {
+		return nil, err
+	}
+	return value.(*listValue), nil
+}
+
+func (lc *LocalCache) getSet(key string, ttlSecond int64, create bool) (*setValue, error) {
+	var newSet func() interface{}
+	if create {
+		newSet = func() interface{} {
+			return &setValue{members: make(map[string]struct{})}
+		}
+	}
+	value, err := lc.getValue(key, ttlSecond, newSet, func(value interface{}) bool {
+		_, ok := value.(*setValue)
+		return ok
+	})
+	if value == nil {
+		return nil, err
+	}
+	return value.(*setValue), nil
+}
+
+// HSet sets field of the hash stored at key, the hash is created if key not exist. ttlSecond is the ttl of the whole hash, use ttltype.Keep to keep the ttl left
+func (lc *LocalCache) HSet(key string, field string, value interface{}, ttlSecond int64) error {
+	h, err := lc.getHash(key, ttlSecond, true)
+	if err != nil {
+		return err
+	}
+	h.lock.Lock()
+	h.fields[field] = value
+	h.lock.Unlock()
+	return nil
+}
+
+// HGet returns the value of field in the hash stored at key
+func (lc *LocalCache) HGet(key string, field string) (value interface{}, exist bool, err error) {
+	h, err := lc.getHash(key, 0, false)
+	if h == nil {
+		return nil, false, err
+	}
+	h.lock.RLock()
+	value, exist = h.fields[field]
+	h.lock.RUnlock()
+	return value, exist, nil
+}
+
+// HDel removes fields from the hash stored at key, returns the count of removed fields
+func (lc *LocalCache) HDel(key string, fields ...string) (int64, error) {
+	h, err := lc.getHash(key, 0, false)
+	if h == nil {
+		return 0, err
+	}
+	count := int64(0)
+	h.lock.Lock()
+	for _, field := range fields {
+		if _, exist := h.fields[field]; exist {
+			delete(h.fields, field)
+			count++
+		}
+	}
+	h.lock.Unlock()
+	return count, nil
+}
+
+// HGetAll returns a copy of all fields in the hash stored at key
+func (lc *LocalCache) HGetAll(key string) (map[string]interface{}, error) {
+	h, err := lc.getHash(key, 0, false)
+	if h == nil {
+		return map[string]interface{}{}, err
+	}
+	h.lock.RLock()
+	result := make(map[string]interface{}, len(h.fields))
+	for field, value := range h.fields {
+		result[field] = value
+	}
+	h.lock.RUnlock()
+	return result, nil
+}
+
+// LPush inserts values at the head of the list stored at key, the list is created if key not exist. Returns the length of the list
+func (lc *LocalCache) LPush(key string, ttlSecond int64, values ...interface{}) (int64, error) {
+	l, err := lc.getList(key, ttlSecond, true)
+	if err != nil {
+		return 0, err
+	}
+	l.lock.Lock()
+	l.items = append(l.items, values...)
+	length := int64(len(l.items))
+	l.lock.Unlock()
+	return length, nil
+}
+
+// RPop removes and returns the last item of the list stored at key
+func (lc *LocalCache) RPop(key string) (value interface{}, exist bool, err error) {
+	l, err := lc.getList(key, 0, false)
+	if l == nil {
+		return nil, false, err
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if len(l.items) == 0 {
+		return nil, false, nil
+	}
+	value = l.items[0]
+	l.items[0] = nil
+	l.items = l.items[1:]
+	return value, true, nil
+}
+
+// LRange returns items of the list stored at key within [start, stop], negative index counts from the tail like Redis
+func (lc *LocalCache) LRange(key string, start int64, stop int64) ([]interface{}, error) {
+	l, err := lc.getList(key, 0, false)
+	if l == nil {
+		return []interface{}{}, err
+	}
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	length := int64(len(l.items))
+	if start < 0 {
+		start += length
+	}
+	if stop < 0 {
+		stop += length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= length {
+		stop = length - 1
+	}
+	if start > stop {
+		return []interface{}{}, nil
+	}
+	result := make([]interface{}, 0, stop-start+1)
+	for i := start; i <= stop; i++ {
+		result = append(result, l.items[length-1-i])
+	}
+	return result, nil
+}
+
+// SAdd adds members to the set stored at key, the set is created if key not exist. Returns the count of new members
+func (lc *LocalCache) SAdd(key string, ttlSecond int64, members ...string) (int64, error) {
+	s, err := lc.getSet(key, ttlSecond, true)
+	if err != nil {
+		return 0, err
+	}
+	count := int64(0)
+	s.lock.Lock()
+	for _, member := range members {
+		if _, exist := s.members[member]; !exist {
+			s.members[member] = struct{}{}
+			count++
+		}
+	}
+	s.lock.Unlock()
+	return count, nil
+}
+
+// SIsMember reports whether member is in the set stored at key
+func (lc *LocalCache) SIsMember(key string, member string) (bool, error) {
+	s, err := lc.getSet(key, 0, false)
+	if s == nil {
+		return false, err
+	}
+	s.lock.RLock()
+	_, exist := s.members[member]
+	s.lock.RUnlock()
+	return exist, nil
+}
+
+// SMembers returns all members of the set stored at key
+func (lc *LocalCache) SMembers(key string) ([]string, error) {
+	s, err := lc.getSet(key, 0, false)
+	if s == nil {
+		return []string{}, err
+	}
+	s.lock.RLock()
+	result := make([]string, 0, len(s.members))
+	for member := range s.members {
+		result = append(result, member)
+	}
+	s.lock.RUnlock()
+	return result, nil
+}
@@ -0,0 +1,120 @@
+package go_fast_cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultHighWatermark of the count limit starts an eviction pass
+	DefaultHighWatermark = 1.0
+	// DefaultLowWatermark of the count limit is the key count an eviction pass evicts down to
+	DefaultLowWatermark = 1.0 - DefaultDeleteOverLimitRate
+
+	// EvictBatchSize is the max count of keys evicted while holding the sortedset lock
+	EvictBatchSize = 1000
+	// EvictCheckIntervalMillisecond is the interval of the over limit check, Set also starts a pass when crossing the high watermark
+	EvictCheckIntervalMillisecond = 1000
+)
+
+// EvictionStats of the over limit eviction passes
+type EvictionStats struct {
+	Passes           int64         // count of passes
+	Evicted          int64         // keys evicted by all passes
+	LastPassEvicted  int64         // keys evicted by the last pass
+	LastPassDuration time.Duration // duration of the last pass
+	LastPassAt       time.Time     // start time of the last pass
+}
+
+// SetEvictionWatermark sets the watermarks as rates of the count limit. When the key count reaches high*limit, keys with the most recent expiration time are evicted in batches until low*limit.
+// Default is high 1.0 and low 0.85, high is in (0, 1] and low is in (0, high)
+func (lc *LocalCache) SetEvictionWatermark(high float64, low float64) {
+	if high <= 0 || high > 1 {
+		high = DefaultHighWatermark
+	}
+	if low <= 0 || low >= high {
+		low = high * DefaultLowWatermark
+	}
+	lc.evictLock.Lock()
+	lc.highWatermark = high
+	lc.lowWatermark = low
+	lc.evictLock.Unlock()
+	lc.updateHighCount()
+}
+
+// updateHighCount caches the key count of the high watermark for checkOverLimit
+func (lc *LocalCache) updateHighCount() {
+	high, _ := lc.watermarks()
+	atomic.StoreInt64(&lc.highCount, high)
+}
+
+// GetEvictionStats returns the stats of eviction passes
+func (lc *LocalCache) GetEvictionStats() EvictionStats {
+	lc.evictLock.Lock()
+	defer lc.evictLock.Unlock()
+	return lc.evictStats
+}
+
+// watermarks returns the key counts of the high and low watermark
+func (lc *LocalCache) watermarks() (high int64, low int64) {
+	lc.evictLock.Lock()
+	defer lc.evictLock.Unlock()
+	return int64(float64(lc.countLimit) * lc.highWatermark), int64(float64(lc.countLimit) * lc.lowWatermark)
+}
+
+// checkOverLimit is called when a new key is added, it wakes up scheduleDeleteOverLimit without blocking
+func (lc *LocalCache) checkOverLimit() {
+	if lc.s.Len() < atomic.LoadInt64(&lc.highCount) {
+		return
+	}
+	select {
+	case lc.evictSignal <- struct{}{}:
+	default:
+	}
+}
+
+func (lc *LocalCache) scheduleDeleteOverLimit() {
+	lc.sup.Go("scheduleDeleteOverLimit", func() {
+		for {
+			select {
+			case <-lc.evictSignal:
+			case <-time.After(EvictCheckIntervalMillisecond * time.Millisecond):
+			}
+			//log.Println("scheduleDeleteOverLimit start")
+			high, low := lc.watermarks()
+			if lc.s.Len() >= high {
+				lc.evict(low)
+			}
+		}
+	})
+}
+
+// evict removes keys with the most recent expiration time in batches until the key count is low
+func (lc *LocalCache) evict(low int64) {
+	start := time.Now()
+	evicted := int64(0)
+	for {
+		over := lc.s.Len() - low
+		if over <= 0 {
+			break
+		}
+		if over > EvictBatchSize {
+			over = EvictBatchSize
+		}
+		removed := lc.s.RemoveByRank(0, over)
+		if removed == 0 {
+			break
+		}
+		evicted += removed
+	}
+
+	duration := time.Since(start)
+	lc.evictLock.Lock()
+	lc.evictStats.Passes++
+	lc.evictStats.Evicted += evicted
+	lc.evictStats.LastPassEvicted = evicted
+	lc.evictStats.LastPassDuration = duration
+	lc.evictStats.LastPassAt = start
+	lc.evictLock.Unlock()
+	lc.llog.Infof("eviction pass evicted %d keys in %v, %d keys left", evicted, duration, lc.s.Len())
+}
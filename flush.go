@@ -0,0 +1,76 @@
+package go_fast_cache
+
+import (
+	"github.com/daqnext/go-fast-cache/sortedset"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Snapshot is a dataset built offline and swapped in by LocalCache.Replace. It is not safe for concurrent use
+type Snapshot struct {
+	s *sortedset.Snapshot
+}
+
+// NewSnapshot makes a new empty Snapshot
+func NewSnapshot() *Snapshot {
+	return &Snapshot{
+		s: sortedset.MakeSnapshot(),
+	}
+}
+
+// Set Set key value with expire time in second, ttlSecond <= 0 is ignored
+func (sn *Snapshot) Set(key string, value interface{}, ttlSecond int64) {
+	if ttlSecond <= 0 {
+		return
+	}
+	if ttlSecond > MaxTTLSecond {
+		ttlSecond = MaxTTLSecond
+	}
+	sn.s.Add(key, sortedset.Element{Score: time.Now().Unix() + ttlSecond, TTL: ttlSecond, Value: value})
+}
+
+// Len returns the key count of the snapshot
+func (sn *Snapshot) Len() int64 {
+	return sn.s.Len()
+}
+
+// Flush atomically deletes all keys, returns the count of deleted keys
+func (lc *LocalCache) Flush() int64 {
+	return lc.Replace(NewSnapshot())
+}
+
+// Replace atomically swaps the whole dataset with the snapshot, readers see either the old or the new dataset.
+// Tags of the old keys are dropped. The snapshot must not be used afterwards. Returns the count of replaced keys
+func (lc *LocalCache) Replace(snapshot *Snapshot) int64 {
+	lc.tagLock.Lock()
+	defer lc.tagLock.Unlock()
+	lc.nsLock.Lock()
+	defer lc.nsLock.Unlock()
+
+	nsLen := make(map[*Namespace]int64, len(lc.namespaces))
+	if len(lc.namespaces) > 0 {
+		snapshot.s.Range(func(key string, e sortedset.Element) bool {
+			if i := strings.Index(key, NamespaceSeparator); i >= 0 {
+				if ns, exist := lc.namespaces[key[:i]]; exist {
+					nsLen[ns]++
+				}
+			}
+			return true
+		})
+	}
+
+	old := lc.s.Replace(snapshot.s)
+
+	lc.tagKeys = make(map[string]map[string]struct{})
+	lc.keyTags = make(map[string][]string)
+	atomic.StoreInt64(&lc.tagged, 0)
+	for _, ns := range lc.namespaces {
+		atomic.StoreInt64(&ns.len, nsLen[ns])
+	}
+	lc.slideLock.Lock()
+	lc.slidePending = make(map[string]struct{})
+	lc.slideLock.Unlock()
+	lc.checkOverLimit()
+	return old
+}
@@ -1,18 +1,3 @@
 module github.com/daqnext/go-fast-cache
 
-go 1.17
-
-require (
-	github.com/daqnext/LocalLog v0.2.4
-	github.com/daqnext/go-smart-routine v0.1.5
-)
-
-require (
-	github.com/antonfisher/nested-logrus-formatter v1.3.1 // indirect
-	github.com/daqnext/fastjson v1.0.0 // indirect
-	github.com/daqnext/jsonparser v1.1.2 // indirect
-	github.com/daqnext/utils v0.0.6 // indirect
-	github.com/natefinch/lumberjack v2.0.0+incompatible // indirect
-	github.com/sirupsen/logrus v1.8.1 // indirect
-	golang.org/x/sys v0.0.0-20210927094055-39ccf1dd6fa6 // indirect
-)
+go 1.20
@@ -0,0 +1,56 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Codec encodes cache values to http bodies and decodes request bodies to values
+type Codec interface {
+	ContentType() string
+	Marshal(value interface{}) ([]byte, error)
+	Unmarshal(data []byte) (interface{}, error)
+}
+
+// JSONCodec stores PUT bodies as decoded json values (map[string]interface{}, []interface{}, string, float64, bool)
+type JSONCodec struct{}
+
+func (JSONCodec) ContentType() string {
+	return "application/json"
+}
+
+func (JSONCodec) Marshal(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (JSONCodec) Unmarshal(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// RawCodec stores PUT bodies as []byte, only string and []byte values can be read
+type RawCodec struct{}
+
+var errNotRaw = errors.New("value is not string or []byte")
+
+func (RawCodec) ContentType() string {
+	return "application/octet-stream"
+}
+
+func (RawCodec) Marshal(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	default:
+		return nil, errNotRaw
+	}
+}
+
+func (RawCodec) Unmarshal(data []byte) (interface{}, error) {
+	return data, nil
+}
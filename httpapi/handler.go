@@ -0,0 +1,203 @@
+package httpapi
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	localcache "github.com/daqnext/go-fast-cache"
+	"github.com/daqnext/go-fast-cache/ttltype"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxBodyBytes limits the size of PUT bodies
+	MaxBodyBytes = 32 << 20
+)
+
+// Config of Handler
+type Config struct {
+	// Codec encodes and decodes values, default is JSONCodec
+	Codec Codec
+	// Token enables auth if not empty, requests must carry header "Authorization: Bearer <Token>"
+	Token string
+}
+
+// Handler exposes a LocalCache over http:
+//
+//	GET    /keys/{key}          value encoded by Codec, ttl left in header X-TTL
+//	PUT    /keys/{key}?ttl=300  set value decoded from body by Codec, ttl defaults to ttltype.Keep
+//	DELETE /keys/{key}
+//	GET    /keys?prefix=a&limit=100
+//	GET    /stats
+//	POST   /flush
+type Handler struct {
+	lc     *localcache.LocalCache
+	config Config
+}
+
+type keyInfo struct {
+	Key string `json:"key"`
+	TTL int64  `json:"ttl"`
+}
+
+// New returns a Handler of lc
+func New(lc *localcache.LocalCache, config Config) *Handler {
+	if config.Codec == nil {
+		config.Codec = JSONCodec{}
+	}
+	return &Handler{
+		lc:     lc,
+		config: config,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	path := r.URL.EscapedPath()
+	switch {
+	case strings.HasPrefix(path, "/keys/"):
+		key, err := url.PathUnescape(strings.TrimPrefix(path, "/keys/"))
+		if err != nil || key == "" {
+			writeError(w, http.StatusBadRequest, "invalid key")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			h.get(w, key)
+		case http.MethodPut:
+			h.put(w, r, key)
+		case http.MethodDelete:
+			h.lc.Delete(key)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case path == "/keys":
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		h.list(w, r)
+	case path == "/stats":
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"len":         h.lc.GetLen(),
+			"count_limit": h.lc.GetCountLimit(),
+		})
+	case path == "/flush":
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		h.flush(w)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (h *Handler) authorized(r *http.Request) bool {
+	if h.config.Token == "" {
+		return true
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.config.Token)) == 1
+}
+
+func (h *Handler) get(w http.ResponseWriter, key string) {
+	value, ttl, exist := h.lc.Get(key)
+	if !exist {
+		writeError(w, http.StatusNotFound, "key not found")
+		return
+	}
+	body, err := h.config.Codec.Marshal(value)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", h.config.Codec.ContentType())
+	w.Header().Set("X-TTL", strconv.FormatInt(ttl, 10))
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+func (h *Handler) put(w http.ResponseWriter, r *http.Request, key string) {
+	ttl := ttltype.Keep
+	if ttlStr := r.URL.Query().Get("ttl"); ttlStr != "" {
+		var err error
+		ttl, err = strconv.ParseInt(ttlStr, 10, 64)
+		if err != nil || ttl < 0 {
+			writeError(w, http.StatusBadRequest, "invalid ttl")
+			return
+		}
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	value, err := h.config.Codec.Unmarshal(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.lc.Set(key, value, ttl)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	limit := -1
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+	keys := make([]keyInfo, 0)
+	h.lc.Range(func(key string, value interface{}, ttl int64) bool {
+		if !strings.HasPrefix(key, prefix) {
+			return true
+		}
+		keys = append(keys, keyInfo{Key: key, TTL: ttl})
+		return true
+	})
+	//limit after sorting, so the same first keys are listed on every call
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Key < keys[j].Key
+	})
+	if limit >= 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	writeJSON(w, http.StatusOK, keys)
+}
+
+func (h *Handler) flush(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"flushed": h.lc.Flush(),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{
+		"error": msg,
+	})
+}
@@ -0,0 +1,140 @@
+package invalidation
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	localcache "github.com/daqnext/go-fast-cache"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DedupSize is the count of recent event ids remembered to drop duplicated deliveries
+	DedupSize = 4096
+)
+
+type Op uint8
+
+const (
+	OpDelete Op = iota + 1
+	OpDeleteByPrefix
+	OpFlush
+)
+
+// Event is an invalidation broadcast to all instances
+type Event struct {
+	ID     string `json:"id"`
+	Origin string `json:"origin"`
+	Op     Op     `json:"op"`
+	Key    string `json:"key,omitempty"` // key of OpDelete or prefix of OpDeleteByPrefix
+}
+
+// Transport delivers events between instances. Redis pub/sub, NATS or any broker can be plugged by implementing it.
+// Delivering an event back to its publisher or delivering it more than once is allowed, Bus drops them
+type Transport interface {
+	Publish(e Event) error
+	// Subscribe registers the handler of received events, it is called once by the Bus
+	Subscribe(handler func(e Event)) error
+	Close() error
+}
+
+// Bus applies invalidations to the local cache and broadcasts them to other instances
+type Bus struct {
+	lc        *localcache.LocalCache
+	transport Transport
+	origin    string
+	seq       uint64
+
+	lock     sync.Mutex
+	seen     map[string]struct{}
+	seenRing []string
+	seenPos  int
+}
+
+// New returns a Bus of lc and subscribes to the transport
+func New(lc *localcache.LocalCache, transport Transport) (*Bus, error) {
+	origin := make([]byte, 8)
+	if _, err := rand.Read(origin); err != nil {
+		return nil, err
+	}
+	b := &Bus{
+		lc:        lc,
+		transport: transport,
+		origin:    hex.EncodeToString(origin),
+		seen:      make(map[string]struct{}, DedupSize),
+		seenRing:  make([]string, DedupSize),
+	}
+	if err := transport.Subscribe(b.receive); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Origin returns the id of this instance carried by published events
+func (b *Bus) Origin() string {
+	return b.origin
+}
+
+// Delete deletes key locally and on other instances
+func (b *Bus) Delete(key string) error {
+	b.lc.Delete(key)
+	return b.publish(OpDelete, key)
+}
+
+// DeleteByPrefix deletes keys with the prefix locally and on other instances, returns the count deleted locally
+func (b *Bus) DeleteByPrefix(prefix string) (int64, error) {
+	count := b.lc.DeleteByPrefix(prefix)
+	return count, b.publish(OpDeleteByPrefix, prefix)
+}
+
+// Flush deletes all keys locally and on other instances
+func (b *Bus) Flush() error {
+	b.lc.Flush()
+	return b.publish(OpFlush, "")
+}
+
+// Close closes the transport
+func (b *Bus) Close() error {
+	return b.transport.Close()
+}
+
+func (b *Bus) publish(op Op, key string) error {
+	e := Event{
+		ID:     fmt.Sprintf("%s-%d", b.origin, atomic.AddUint64(&b.seq, 1)),
+		Origin: b.origin,
+		Op:     op,
+		Key:    key,
+	}
+	return b.transport.Publish(e)
+}
+
+func (b *Bus) receive(e Event) {
+	if e.Origin == b.origin || b.duplicated(e.ID) {
+		return
+	}
+	switch e.Op {
+	case OpDelete:
+		b.lc.Delete(e.Key)
+	case OpDeleteByPrefix:
+		b.lc.DeleteByPrefix(e.Key)
+	case OpFlush:
+		b.lc.Flush()
+	}
+}
+
+// duplicated reports whether the event id has been seen, and remembers it
+func (b *Bus) duplicated(id string) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if _, exist := b.seen[id]; exist {
+		return true
+	}
+	if old := b.seenRing[b.seenPos]; old != "" {
+		delete(b.seen, old)
+	}
+	b.seenRing[b.seenPos] = id
+	b.seenPos = (b.seenPos + 1) % DedupSize
+	b.seen[id] = struct{}{}
+	return false
+}
@@ -0,0 +1,68 @@
+package invalidation
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrClosed = errors.New("invalidation: transport closed")
+
+// MemoryHub connects in-process transports, events are delivered synchronously to every subscribed transport including the publisher
+type MemoryHub struct {
+	lock     sync.RWMutex
+	handlers map[*MemoryTransport]func(e Event)
+}
+
+// NewMemoryHub makes a new MemoryHub
+func NewMemoryHub() *MemoryHub {
+	return &MemoryHub{
+		handlers: make(map[*MemoryTransport]func(e Event)),
+	}
+}
+
+// Transport returns a new transport connected to the hub
+func (h *MemoryHub) Transport() *MemoryTransport {
+	return &MemoryTransport{hub: h}
+}
+
+// MemoryTransport is a Transport of MemoryHub
+type MemoryTransport struct {
+	hub    *MemoryHub
+	closed bool
+}
+
+func (t *MemoryTransport) Publish(e Event) error {
+	t.hub.lock.RLock()
+	if t.closed {
+		t.hub.lock.RUnlock()
+		return ErrClosed
+	}
+	handlers := make([]func(e Event), 0, len(t.hub.handlers))
+	for _, handler := range t.hub.handlers {
+		handlers = append(handlers, handler)
+	}
+	t.hub.lock.RUnlock()
+
+	for _, handler := range handlers {
+		handler(e)
+	}
+	return nil
+}
+
+func (t *MemoryTransport) Subscribe(handler func(e Event)) error {
+	t.hub.lock.Lock()
+	defer t.hub.lock.Unlock()
+	if t.closed {
+		return ErrClosed
+	}
+	t.hub.handlers[t] = handler
+	return nil
+}
+
+func (t *MemoryTransport) Close() error {
+	t.hub.lock.Lock()
+	defer t.hub.lock.Unlock()
+	t.closed = true
+	delete(t.hub.handlers, t)
+	return nil
+}
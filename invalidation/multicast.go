@@ -0,0 +1,140 @@
+package invalidation
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+)
+
+const (
+	// maxDatagramSize is the max size of an encoded event
+	maxDatagramSize = 8192
+)
+
+// MulticastTransport broadcasts events as json udp datagrams to a multicast group, every instance in the group joins with the same address
+type MulticastTransport struct {
+	group    *net.UDPAddr
+	sendConn *net.UDPConn
+	recvConn *net.UDPConn
+
+	lock   sync.Mutex
+	closed bool
+}
+
+// NewMulticastTransport joins the multicast group address like "239.0.0.1:9999", events are received and sent on the interface ifaceName.
+// ifaceName empty uses the system default interface
+func NewMulticastTransport(address string, ifaceName string) (*MulticastTransport, error) {
+	group, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	var iface *net.Interface
+	if ifaceName != "" {
+		iface, err = net.InterfaceByName(ifaceName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	recvConn, err := net.ListenMulticastUDP("udp", iface, group)
+	if err != nil {
+		return nil, err
+	}
+	recvConn.SetReadBuffer(maxDatagramSize * 256)
+	sendConn, err := net.DialUDP("udp", nil, group)
+	if err != nil {
+		recvConn.Close()
+		return nil, err
+	}
+	if iface != nil {
+		if err := setMulticastInterface(sendConn, group, iface); err != nil {
+			recvConn.Close()
+			sendConn.Close()
+			return nil, err
+		}
+	}
+	return &MulticastTransport{
+		group:    group,
+		sendConn: sendConn,
+		recvConn: recvConn,
+	}, nil
+}
+
+// setMulticastInterface makes conn send the datagrams to group on iface instead of the interface of the default route
+func setMulticastInterface(conn *net.UDPConn, group *net.UDPAddr, iface *net.Interface) error {
+	var ip4 [4]byte
+	if group.IP.To4() != nil {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+				copy(ip4[:], ipNet.IP.To4())
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.New("invalidation: no ipv4 address on interface " + iface.Name)
+		}
+	}
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var sockErr error
+	err = raw.Control(func(fd uintptr) {
+		if group.IP.To4() != nil {
+			sockErr = setMulticastIPv4(fd, ip4)
+		} else {
+			sockErr = setMulticastIPv6(fd, iface.Index)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+func (t *MulticastTransport) Publish(e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = t.sendConn.Write(data)
+	return err
+}
+
+func (t *MulticastTransport) Subscribe(handler func(e Event)) error {
+	go func() {
+		buf := make([]byte, maxDatagramSize)
+		for {
+			n, _, err := t.recvConn.ReadFromUDP(buf)
+			if err != nil {
+				t.lock.Lock()
+				closed := t.closed
+				t.lock.Unlock()
+				if closed {
+					return
+				}
+				continue
+			}
+			var e Event
+			if err := json.Unmarshal(buf[:n], &e); err != nil {
+				continue
+			}
+			handler(e)
+		}
+	}()
+	return nil
+}
+
+func (t *MulticastTransport) Close() error {
+	t.lock.Lock()
+	t.closed = true
+	t.lock.Unlock()
+	t.sendConn.Close()
+	return t.recvConn.Close()
+}
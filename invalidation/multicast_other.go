@@ -0,0 +1,15 @@
+//go:build !unix && !windows
+
+package invalidation
+
+import "errors"
+
+var errMulticastInterface = errors.New("invalidation: choosing the multicast interface is not supported on this platform")
+
+func setMulticastIPv4(fd uintptr, ip [4]byte) error {
+	return errMulticastInterface
+}
+
+func setMulticastIPv6(fd uintptr, index int) error {
+	return errMulticastInterface
+}
@@ -0,0 +1,11 @@
+package invalidation
+
+import "syscall"
+
+func setMulticastIPv4(fd uintptr, ip [4]byte) error {
+	return syscall.SetsockoptInet4Addr(syscall.Handle(fd), syscall.IPPROTO_IP, syscall.IP_MULTICAST_IF, ip)
+}
+
+func setMulticastIPv6(fd uintptr, index int) error {
+	return syscall.SetsockoptInt(syscall.Handle(fd), syscall.IPPROTO_IPV6, syscall.IPV6_MULTICAST_IF, index)
+}
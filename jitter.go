@@ -0,0 +1,45 @@
+package go_fast_cache
+
+import (
+	"math"
+	"math/rand"
+	"sync/atomic"
+)
+
+// SetTTLJitter spreads the expire time of keys set with the same ttl: ttl is randomly changed within ±rate*ttl at Set time. rate is in [0, 1], default 0
+func (lc *LocalCache) SetTTLJitter(rate float64) {
+	if rate < 0 {
+		rate = 0
+	}
+	if rate > 1 {
+		rate = 1
+	}
+	atomic.StoreUint64(&lc.jitterRate, math.Float64bits(rate))
+}
+
+// SetTTLJitterSecond spreads the expire time of keys: ttl is randomly changed within ±second at Set time. The larger range of SetTTLJitter and SetTTLJitterSecond is used
+func (lc *LocalCache) SetTTLJitterSecond(second int64) {
+	if second < 0 {
+		second = 0
+	}
+	atomic.StoreInt64(&lc.jitterSecond, second)
+}
+
+// jitter returns ttlSecond randomly changed by the jitter config, it is kept within [1, MaxTTLSecond]
+func (lc *LocalCache) jitter(ttlSecond int64) int64 {
+	j := int64(math.Round(float64(ttlSecond) * math.Float64frombits(atomic.LoadUint64(&lc.jitterRate))))
+	if second := atomic.LoadInt64(&lc.jitterSecond); second > j {
+		j = second
+	}
+	if j == 0 {
+		return ttlSecond
+	}
+	ttlSecond += rand.Int63n(2*j+1) - j
+	if ttlSecond < 1 {
+		return 1
+	}
+	if ttlSecond > MaxTTLSecond {
+		return MaxTTLSecond
+	}
+	return ttlSecond
+}
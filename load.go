@@ -0,0 +1,118 @@
+package go_fast_cache
+
+import (
+	"context"
+	"github.com/daqnext/go-fast-cache/sortedset"
+	"github.com/daqnext/go-fast-cache/supervisor"
+	"math"
+	"math/rand"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultEarlyExpirationBeta = 1.0
+)
+
+type loadCall struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// SetEarlyExpirationBeta sets beta of the probabilistic early expiration (XFetch) of GetOrLoad.
+// A key loaded by GetOrLoad is reloaded before it expires with a probability growing as the expire time gets closer and as the load gets slower,
+// larger beta reloads earlier, 0 disables early expiration. Default is DefaultEarlyExpirationBeta
+func (lc *LocalCache) SetEarlyExpirationBeta(beta float64) {
+	if beta < 0 {
+		beta = 0
+	}
+	atomic.StoreUint64(&lc.beta, math.Float64bits(beta))
+}
+
+// GetOrLoad returns the value of key, or calls loader and sets its value with ttlSecond if key not exist.
+// Concurrent calls of the same key share one loader call, loader errors are returned and not cached.
+// If loader panics, the call which started it panics again and the waiting calls return a *supervisor.PanicError
+func (lc *LocalCache) GetOrLoad(key string, ttlSecond int64, loader func() (interface{}, error)) (interface{}, error) {
+	return lc.GetOrLoadCtx(context.Background(), key, ttlSecond, func(ctx context.Context) (interface{}, error) {
+		return loader()
+	})
+}
+
+// GetOrLoadCtx is GetOrLoad passing ctx to loader and the hooks. A call waiting for the loader of another call returns the ctx error when ctx is done,
+// the shared loader gets the ctx of the call which started it
+func (lc *LocalCache) GetOrLoadCtx(ctx context.Context, key string, ttlSecond int64, loader func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	value, _, exist := lc.handler().Get(withOp(ctx, &coreOp{xfetch: true}), key)
+	for _, h := range lc.getHooks() {
+		h.OnGet(ctx, key, exist)
+	}
+	if exist {
+		return value, nil
+	}
+	return lc.load(ctx, key, ttlSecond, loader)
+}
+
+// expireEarly reports whether the unexpired element should be reloaded now, XFetch: now - delta * beta * ln(rand) >= expiry
+func (lc *LocalCache) expireEarly(e sortedset.Element) bool {
+	beta := math.Float64frombits(atomic.LoadUint64(&lc.beta))
+	if beta == 0 || e.Delta == 0 || e.Score == persistScore {
+		return false
+	}
+	now := float64(time.Now().UnixNano()) / float64(time.Second)
+	delta := float64(e.Delta) / 1000
+	return now-delta*beta*math.Log(rand.Float64()) >= float64(e.Score)
+}
+
+func (lc *LocalCache) load(ctx context.Context, key string, ttlSecond int64, loader func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	lc.loadLock.Lock()
+	if call, exist := lc.loadCalls[key]; exist {
+		lc.loadLock.Unlock()
+		select {
+		case <-call.done:
+			return call.value, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &loadCall{done: make(chan struct{})}
+	lc.loadCalls[key] = call
+	lc.loadLock.Unlock()
+
+	loaded := false
+	defer func() {
+		var r interface{}
+		if !loaded {
+			//r is nil if loader called runtime.Goexit
+			r = recover()
+			call.value, call.err = nil, &supervisor.PanicError{Value: r, Stack: debug.Stack()}
+		}
+		lc.loadLock.Lock()
+		delete(lc.loadCalls, key)
+		lc.loadLock.Unlock()
+		close(call.done)
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	start := time.Now()
+	call.value, call.err = loader(ctx)
+	loaded = true
+	duration := time.Since(start)
+	for _, h := range lc.getHooks() {
+		h.OnLoad(ctx, key, duration, call.err)
+	}
+	if call.err != nil {
+		return call.value, call.err
+	}
+	delta := duration.Milliseconds()
+	if delta == 0 {
+		delta = 1
+	}
+	lc.handler().Set(withOp(ctx, &coreOp{delta: delta}), key, call.value, ttlSecond)
+	return call.value, nil
+}
@@ -1,12 +1,15 @@
 package go_fast_cache
 
 import (
-	locallog "github.com/daqnext/LocalLog/log"
+	"context"
+	"github.com/daqnext/go-fast-cache/logging"
 	"github.com/daqnext/go-fast-cache/sortedset"
+	"github.com/daqnext/go-fast-cache/supervisor"
 	"github.com/daqnext/go-fast-cache/ttltype"
-	"github.com/daqnext/go-smart-routine/sr"
-	"math/rand"
+	"math"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,150 +22,310 @@ const (
 	DefaultDeleteExpireIntervalSecond = 5
 
 	DefaultDeleteOverLimitRate = 0.15
+
+	// ExpireBatchSize is the max count of expired keys removed while holding the sortedset lock
+	ExpireBatchSize = 1000
+	// ExpireTimeBudgetMillisecond is the max time of one scheduleDeleteExpire cycle
+	ExpireTimeBudgetMillisecond = 25
+	// ExpireFastIntervalMillisecond is the interval of the next cycle if the last one ran out of time budget with expired keys left
+	ExpireFastIntervalMillisecond = 100
 )
 
 type LocalCache struct {
 	s          *sortedset.SortedSet
 	countLimit int64
 	lock       sync.Mutex
-	llog       *locallog.LocalLog
+	llog       logging.Logger
+	sup        *supervisor.Supervisor
+
+	tagLock sync.RWMutex                   // tagged is changed under the write lock, untagged writes hold the read lock
+	tagKeys map[string]map[string]struct{} // tag => keys
+	keyTags map[string][]string            // key => tags
+	tagged  int64
+
+	sliding      int32
+	slideLock    sync.Mutex
+	slidePending map[string]struct{}
+
+	jitterRate   uint64 // float64 bits
+	jitterSecond int64
+	beta         uint64 // float64 bits
+	loadLock     sync.Mutex
+	loadCalls    map[string]*loadCall
+
+	highWatermark float64
+	lowWatermark  float64
+	highCount     int64
+	evictSignal   chan struct{}
+	evictLock     sync.Mutex
+	evictStats    EvictionStats
+
+	hookLock sync.Mutex
+	hooks    atomic.Value // []Hook
+
+	chainLock   sync.Mutex
+	middlewares []Middleware
+	chain       atomic.Value // chain
+
+	randFormat atomic.Value // randFormat
+
+	nsLock     sync.RWMutex
+	namespaces map[string]*Namespace
+	nsCount    int32
 }
 
-// New Instance of localCache, the interval of scheduleDeleteExpire job use the default value 5 seconds
-func New(logger *locallog.LocalLog) *LocalCache {
-	rand.Seed(time.Now().UnixNano())
-	cache := &LocalCache{
-		s:          sortedset.Make(),
-		countLimit: DefaultCountLimit,
-		llog:       logger,
-	}
-	cache.scheduleDeleteExpire(5)
-	cache.scheduleDeleteOverLimit()
-	return cache
+// New Instance of localCache, the interval of scheduleDeleteExpire job use the default value 5 seconds.
+// logger receives janitor panics and eviction passes, if logger is nil nothing is logged
+func New(logger logging.Logger) *LocalCache {
+	return NewWithInterval(DefaultDeleteExpireIntervalSecond, logger)
 }
 
 // NewWithInterval Instance of localCache, param intervalSecond defines the interval of scheduleDeleteExpire job, if intervalSecond <=0,it will use the default value 5 seconds
-func NewWithInterval(intervalSecond int, logger *locallog.LocalLog) *LocalCache {
+func NewWithInterval(intervalSecond int, logger logging.Logger) *LocalCache {
 	if intervalSecond > MaxDeleteExpireIntervalSecond {
 		intervalSecond = MaxDeleteExpireIntervalSecond
 	}
 	if intervalSecond < 1 {
 		intervalSecond = DefaultDeleteExpireIntervalSecond
 	}
+	logger = logging.OrNop(logger)
+	sup := supervisor.New(logger)
 	cache := &LocalCache{
-		s:          sortedset.Make(),
+		s:          sortedset.MakeWithRunner(sup.Go),
 		countLimit: DefaultCountLimit,
 		llog:       logger,
+		sup:        sup,
+		tagKeys:    make(map[string]map[string]struct{}),
+		keyTags:    make(map[string][]string),
+
+		slidePending: make(map[string]struct{}),
+		beta:         math.Float64bits(DefaultEarlyExpirationBeta),
+		loadCalls:    make(map[string]*loadCall),
+
+		highWatermark: DefaultHighWatermark,
+		lowWatermark:  DefaultLowWatermark,
+		evictSignal:   make(chan struct{}, 1),
+
+		namespaces: make(map[string]*Namespace),
 	}
+	cache.updateHighCount()
+	cache.s.OnRemove(cache.onRemoved)
+	cache.s.OnPanic(cache.onSortedSetPanic)
 	cache.scheduleDeleteExpire(intervalSecond)
 	cache.scheduleDeleteOverLimit()
+	cache.scheduleSlide()
 	return cache
 }
 
-// SetCountLimit Key count limit,default is 1000000. The 15% of the keys with the most recent expiration time will be deleted if the number of keys exceeds the limit.
+// SetCountLimit Key count limit,default is 1000000. The keys with the most recent expiration time will be deleted if the number of keys exceeds the high watermark of the limit, see SetEvictionWatermark
 func (lc *LocalCache) SetCountLimit(limit int64) {
 	if limit < MinCountLimit {
 		limit = MinCountLimit
 	}
+	lc.evictLock.Lock()
 	lc.countLimit = limit
+	lc.evictLock.Unlock()
+	lc.updateHighCount()
+}
+
+// GetCountLimit returns the key count limit
+func (lc *LocalCache) GetCountLimit() int64 {
+	return lc.countLimit
 }
 
 func (lc *LocalCache) Get(key string) (value interface{}, ttl int64, exist bool) {
-	//check expire
+	return lc.GetCtx(context.Background(), key)
+}
+
+// get reads key without the middleware chain
+func (lc *LocalCache) get(key string) (value interface{}, ttl int64, exist bool) {
 	e, exist := lc.s.Get(key)
 	if !exist {
 		return nil, 0, false
 	}
+	return lc.getElement(key, e)
+}
+
+// getElement returns the value of the element of key unless it is expired, and extends its expire time if it slides
+func (lc *LocalCache) getElement(key string, e sortedset.Element) (value interface{}, ttl int64, exist bool) {
+	//check expire
 	nowTime := time.Now().Unix()
 	if e.Score <= nowTime {
 		return nil, 0, false
 	}
-	return e.Value, e.Score - nowTime, true
+	if e.Sliding || atomic.LoadInt32(&lc.sliding) == 1 {
+		lc.slide(key, e, nowTime)
+	}
+	return e.Value, ttlLeft(e, nowTime), true
 }
 
 // Set Set key value with expire time, ttl.Keep or second. If key not exist and set ttl ttl.Keep,it will use default ttl 30sec
 func (lc *LocalCache) Set(key string, value interface{}, ttlSecond int64) {
-	if ttlSecond < 0 {
+	lc.SetContext(context.Background(), key, value, ttlSecond)
+}
+
+func (lc *LocalCache) add(ctx context.Context, key string, e sortedset.Element) error {
+	//no key can be tagged while the read lock is held
+	lc.tagLock.RLock()
+	if atomic.LoadInt64(&lc.tagged) == 0 {
+		added, err := lc.s.AddContext(ctx, key, e)
+		lc.tagLock.RUnlock()
+		if added {
+			lc.added(key)
+		}
+		return err
+	}
+	lc.tagLock.RUnlock()
+	//overwrite drops the tags of the old value
+	lc.tagLock.Lock()
+	added, err := lc.s.AddContext(ctx, key, e)
+	if err == nil {
+		lc.untag(key)
+	}
+	lc.tagLock.Unlock()
+	if added {
+		lc.added(key)
+	}
+	return err
+}
+
+func (lc *LocalCache) Delete(key string) {
+	lc.DeleteCtx(context.Background(), key)
+}
+
+// delete deletes key without the middleware chain
+func (lc *LocalCache) delete(key string) {
+	lc.deleteIf(key, nil)
+}
+
+// deleteIf deletes key if f returns true for its element, f nil deletes it unconditionally. Returns whether key is deleted
+func (lc *LocalCache) deleteIf(key string, f func(e sortedset.Element) bool) bool {
+	lc.tagLock.RLock()
+	if atomic.LoadInt64(&lc.tagged) == 0 {
+		removed := lc.s.RemoveIf(key, f)
+		lc.tagLock.RUnlock()
+		if removed {
+			lc.deleted(key)
+		}
+		return removed
+	}
+	lc.tagLock.RUnlock()
+	lc.tagLock.Lock()
+	removed := lc.s.RemoveIf(key, f)
+	if removed {
+		lc.untag(key)
+	}
+	lc.tagLock.Unlock()
+	if removed {
+		lc.deleted(key)
+	}
+	return removed
+}
+
+// added is called after a new key is added
+func (lc *LocalCache) added(key string) {
+	lc.namespaceAdded(key)
+	lc.checkOverLimit()
+}
+
+// deleted is called after a key is deleted, expired or evicted
+func (lc *LocalCache) deleted(key string) {
+	lc.namespaceDeleted(key)
+}
+
+// onRemoved is called by the sortedset when a key is expired or evicted by the background jobs
+func (lc *LocalCache) onRemoved(key string) {
+	lc.deleted(key)
+	if atomic.LoadInt64(&lc.tagged) == 0 {
 		return
 	}
+	lc.tagLock.Lock()
+	defer lc.tagLock.Unlock()
+	//key was set again after removed
+	if _, exist := lc.s.Get(key); exist {
+		return
+	}
+	lc.untag(key)
+}
+
+// DeleteByPrefix deletes all keys with the prefix, returns the count of deleted keys. Empty prefix deletes all keys
+func (lc *LocalCache) DeleteByPrefix(prefix string) int64 {
+	count := int64(0)
+	lc.Range(func(key string, value interface{}, ttl int64) bool {
+		if strings.HasPrefix(key, prefix) {
+			lc.delete(key)
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+// element returns the element stored for value with ttlSecond, false if ttlSecond is invalid
+func (lc *LocalCache) element(key string, value interface{}, ttlSecond int64) (sortedset.Element, bool) {
+	if ttlSecond < 0 {
+		return sortedset.Element{}, false
+	}
 
 	if ttlSecond > MaxTTLSecond {
 		ttlSecond = MaxTTLSecond
 	}
-	var expireTime int64
 
 	if ttlSecond == ttltype.Keep {
 		//keep
-		ttlLeft, exist := lc.ttl(key)
-		if !exist {
-			ttlLeft = 30
+		old, exist := lc.s.Get(key)
+		if exist && old.Score > time.Now().Unix() {
+			return sortedset.Element{Score: old.Score, TTL: old.TTL, Value: value}, true
 		}
-		expireTime = time.Now().Unix() + ttlLeft
-	} else {
-		//new expire
-		expireTime = time.Now().Unix() + ttlSecond
+		ttlSecond = 30
 	}
-	lc.s.Add(key, expireTime, value)
+	//new expire
+	ttlSecond = lc.jitter(ttlSecond)
+	return sortedset.Element{Score: time.Now().Unix() + ttlSecond, TTL: ttlSecond, Value: value}, true
 }
 
-func (lc *LocalCache) Delete(key string) {
-	lc.s.Remove(key)
-}
-
-// TTL get ttl of a key with second
-func (lc *LocalCache) ttl(key string) (int64, bool) {
-	e, exist := lc.s.Get(key)
-	if !exist {
-		return 0, false
-	}
-	ttl := e.Score - time.Now().Unix()
-	if ttl <= 0 {
-		return 0, false
+// deleteExpireCycle removes expired keys in batches until no expired key left or the time budget is used up, returns false if expired keys are left
+func (lc *LocalCache) deleteExpireCycle() bool {
+	start := time.Now()
+	max := start.Unix()
+	for {
+		//a batch not full means all expired keys are removed
+		if lc.s.RemoveByScoreLimit(max, ExpireBatchSize) < ExpireBatchSize {
+			return true
+		}
+		if time.Since(start) >= ExpireTimeBudgetMillisecond*time.Millisecond {
+			return false
+		}
 	}
-	return ttl, true
 }
 
-func (lc *LocalCache) scheduleDeleteOverLimit() {
-	sr.New_Panic_Redo(func() {
-		time.Sleep(500 * time.Millisecond)
+// ScheduleDeleteExpire delete expired keys
+func (lc *LocalCache) scheduleDeleteExpire(intervalSecond int) {
+	lc.sup.Go("scheduleDeleteExpire", func() {
+		interval := time.Duration(intervalSecond) * time.Second
 		for {
-			time.Sleep(1 * time.Second)
-			//log.Println("scheduleDeleteOverLimit start")
-			if lc.s.Len() >= lc.countLimit {
-				deleteCount := float64(lc.countLimit) * DefaultDeleteOverLimitRate
-				lc.s.RemoveByRank(0, int64(deleteCount))
+			time.Sleep(interval)
+			//log.Println("scheduleDeleteExpire start")
+			if lc.deleteExpireCycle() {
+				interval = time.Duration(intervalSecond) * time.Second
+			} else {
+				interval = ExpireFastIntervalMillisecond * time.Millisecond
 			}
 		}
-	}, lc.llog).Start()
+	})
 }
 
-// ScheduleDeleteExpire delete expired keys
-func (lc *LocalCache) scheduleDeleteExpire(intervalSecond int) {
-	sr.New_Panic_Redo(func() {
-		for {
-			time.Sleep(time.Duration(intervalSecond) * time.Second)
-			//log.Println("scheduleDeleteExpire start")
-			max := time.Now().Unix()
-			//remove expired keys
-			lc.s.RemoveByScore(max)
+// Range calls f for each unexpired key with its value and ttl left, ttltype.Persist if the key never expires. Stops if f returns false. Range does not block other operations, keys set during Range may or may not be visited
+func (lc *LocalCache) Range(f func(key string, value interface{}, ttl int64) bool) {
+	nowTime := time.Now().Unix()
+	lc.s.Range(func(key string, e sortedset.Element) bool {
+		if e.Score <= nowTime {
+			return true
 		}
-	}, lc.llog).Start()
+		return f(key, e.Value, ttlLeft(e, nowTime))
+	})
 }
 
 func (lc *LocalCache) GetLen() int64 {
 	return lc.s.Len()
 }
-
-func (lc *LocalCache) SetRand(key string, ttlSecond int64) string {
-	rs := genRandStr(20)
-	lc.Set(key, rs, ttlSecond)
-	return rs
-}
-
-func (lc *LocalCache) GetRand(key string) string {
-	v, _, exist := lc.Get(key)
-	if !exist {
-		return ""
-	}
-	return v.(string)
-}
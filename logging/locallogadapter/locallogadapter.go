@@ -0,0 +1,14 @@
+package locallogadapter
+
+import (
+	locallog "github.com/daqnext/LocalLog/log"
+	"github.com/daqnext/go-fast-cache/logging"
+)
+
+// New returns a Logger writing to l, or a no-op Logger if l is nil
+func New(l *locallog.LocalLog) logging.Logger {
+	if l == nil {
+		return logging.Nop{}
+	}
+	return l
+}
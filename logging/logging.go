@@ -0,0 +1,25 @@
+package logging
+
+// Logger is the leveled logger used by the background jobs of the caches, see the adapter subpackages for log/slog, logrus and LocalLog. The logrus and LocalLog adapters are separate modules, so the cache module does not depend on them
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// Nop is a Logger which discards everything, it is used when no logger is given
+type Nop struct{}
+
+func (Nop) Debugf(format string, args ...interface{}) {}
+func (Nop) Infof(format string, args ...interface{})  {}
+func (Nop) Warnf(format string, args ...interface{})  {}
+func (Nop) Errorf(format string, args ...interface{}) {}
+
+// OrNop returns l, or Nop if l is nil
+func OrNop(l Logger) Logger {
+	if l == nil {
+		return Nop{}
+	}
+	return l
+}
@@ -0,0 +1,13 @@
+module github.com/daqnext/go-fast-cache/logging/logrusadapter
+
+go 1.20
+
+require (
+	github.com/daqnext/go-fast-cache v0.0.0-00010101000000-000000000000
+	github.com/sirupsen/logrus v1.8.1
+)
+
+require golang.org/x/sys v0.13.0 // indirect
+
+// the replace builds against the local go-fast-cache, a release requires the tagged version, see README nested modules
+replace github.com/daqnext/go-fast-cache => ../..
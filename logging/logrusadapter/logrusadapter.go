@@ -0,0 +1,15 @@
+package logrusadapter
+
+import (
+	"github.com/daqnext/go-fast-cache/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// New returns a Logger writing to l, which may be a *logrus.Logger or a *logrus.Entry with fields.
+// If l is nil the logrus standard logger is used
+func New(l logrus.FieldLogger) logging.Logger {
+	if l == nil {
+		return logrus.StandardLogger()
+	}
+	return l
+}
@@ -0,0 +1,35 @@
+//go:build go1.21
+
+package slogadapter
+
+import (
+	"context"
+	"fmt"
+	"github.com/daqnext/go-fast-cache/logging"
+	"log/slog"
+)
+
+type adapter struct {
+	l *slog.Logger
+}
+
+// New returns a Logger writing to l, or to slog.Default() if l is nil
+func New(l *slog.Logger) logging.Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &adapter{l: l}
+}
+
+func (a *adapter) log(level slog.Level, format string, args []interface{}) {
+	ctx := context.Background()
+	if !a.l.Enabled(ctx, level) {
+		return
+	}
+	a.l.Log(ctx, level, fmt.Sprintf(format, args...))
+}
+
+func (a *adapter) Debugf(format string, args ...interface{}) { a.log(slog.LevelDebug, format, args) }
+func (a *adapter) Infof(format string, args ...interface{})  { a.log(slog.LevelInfo, format, args) }
+func (a *adapter) Warnf(format string, args ...interface{})  { a.log(slog.LevelWarn, format, args) }
+func (a *adapter) Errorf(format string, args ...interface{}) { a.log(slog.LevelError, format, args) }
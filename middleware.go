@@ -0,0 +1,131 @@
+package go_fast_cache
+
+import (
+	"context"
+	"github.com/daqnext/go-fast-cache/sortedset"
+)
+
+// Cache is the set of LocalCache operations downstream code can depend on to substitute a test double, see cachetest
+type Cache interface {
+	Get(key string) (value interface{}, ttl int64, exist bool)
+	Set(key string, value interface{}, ttlSecond int64)
+	Delete(key string)
+	GetLen() int64
+	SetRand(key string, ttlSecond int64) string
+	GetRand(key string) string
+}
+
+var _ Cache = (*LocalCache)(nil)
+
+// Handler is the set of value operations middlewares are composed around. A middleware must pass on the ctx it gets or one derived from it,
+// ctx carries the options of the LocalCache method to the end of the chain
+type Handler interface {
+	Get(ctx context.Context, key string) (value interface{}, ttl int64, exist bool)
+	Set(ctx context.Context, key string, value interface{}, ttlSecond int64) error
+	Delete(ctx context.Context, key string)
+}
+
+// Middleware wraps the next Handler of the chain, e.g. to log, normalize keys or encrypt values
+type Middleware func(next Handler) Handler
+
+// Use appends middlewares to the chain, the first middleware added is the outermost.
+// Every method reading, writing or deleting a plain value goes through the chain: Get, Set, Delete, their Ctx variants, SetContext, GetOrLoad, SetWithTags, SetSliding, SetRand, GetRand and VerifyRand.
+// The ttl operations, Range, DeleteByPrefix, InvalidateTag and the data type operations work on the stored keys and values without the chain.
+// A middleware can embed the next Handler to forward the methods it does not intercept
+func (lc *LocalCache) Use(mw ...Middleware) {
+	lc.chainLock.Lock()
+	defer lc.chainLock.Unlock()
+	lc.middlewares = append(lc.middlewares, mw...)
+	var h Handler = core{lc}
+	for i := len(lc.middlewares) - 1; i >= 0; i-- {
+		h = lc.middlewares[i](h)
+	}
+	lc.chain.Store(chain{h})
+}
+
+// chain is stored in lc.chain, atomic.Value needs the same type on every Store
+type chain struct {
+	Handler
+}
+
+// handler returns the outermost Handler of the chain
+func (lc *LocalCache) handler() Handler {
+	if c, ok := lc.chain.Load().(chain); ok {
+		return c.Handler
+	}
+	return core{lc}
+}
+
+// coreOp carries the options of a LocalCache method through the chain to core, and the results of core back
+type coreOp struct {
+	tags    []string
+	sliding bool
+	delta   int64 // load duration of GetOrLoad in milliseconds, for early expiration
+
+	xfetch bool // Get misses an element due for early expiration
+	found  *sortedset.Element
+
+	cond    func(e sortedset.Element) bool // Delete deletes if cond returns true
+	deleted bool
+}
+
+type coreOpKey struct{}
+
+func withOp(ctx context.Context, op *coreOp) context.Context {
+	return context.WithValue(ctx, coreOpKey{}, op)
+}
+
+func opFrom(ctx context.Context) *coreOp {
+	op, _ := ctx.Value(coreOpKey{}).(*coreOp)
+	return op
+}
+
+// core is the end of the middleware chain
+type core struct {
+	lc *LocalCache
+}
+
+func (c core) Get(ctx context.Context, key string) (value interface{}, ttl int64, exist bool) {
+	op := opFrom(ctx)
+	if op == nil {
+		return c.lc.get(key)
+	}
+	e, exist := c.lc.s.Get(key)
+	if !exist || (op.xfetch && c.lc.expireEarly(e)) {
+		return nil, 0, false
+	}
+	value, ttl, exist = c.lc.getElement(key, e)
+	if exist {
+		op.found = &e
+	}
+	return value, ttl, exist
+}
+
+func (c core) Set(ctx context.Context, key string, value interface{}, ttlSecond int64) error {
+	op := opFrom(ctx)
+	if op == nil {
+		return c.lc.setContext(ctx, key, value, ttlSecond)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	e, ok := c.lc.element(key, value, ttlSecond)
+	if !ok {
+		return nil
+	}
+	e.Sliding = op.sliding
+	e.Delta = op.delta
+	if len(op.tags) > 0 {
+		return c.lc.addTagged(ctx, key, e, op.tags)
+	}
+	return c.lc.add(ctx, key, e)
+}
+
+func (c core) Delete(ctx context.Context, key string) {
+	op := opFrom(ctx)
+	if op == nil {
+		c.lc.delete(key)
+		return
+	}
+	op.deleted = c.lc.deleteIf(key, op.cond)
+}
@@ -0,0 +1,171 @@
+package go_fast_cache
+
+import (
+	"github.com/daqnext/go-fast-cache/sortedset"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// NamespaceSeparator separates the namespace name and the key
+const NamespaceSeparator = ":"
+
+// Namespace is a view of LocalCache which prefixes keys with its name. All namespaces share the expiry index and the count limit of the cache
+type Namespace struct {
+	lc     *LocalCache
+	name   string
+	prefix string
+
+	quota    int64
+	len      int64
+	hits     int64
+	misses   int64
+	sets     int64
+	deletes  int64
+	rejected int64
+
+	quotaLock sync.Mutex // serializes Sets when there is a quota, so new keys can not pass the check together
+}
+
+// NamespaceStats of a Namespace
+type NamespaceStats struct {
+	Len      int64
+	Quota    int64
+	Hits     int64
+	Misses   int64
+	Sets     int64
+	Deletes  int64
+	Rejected int64 // Sets of new keys rejected by the quota
+}
+
+// Namespace returns the namespace of name, it is created on first use. Panics if name contains NamespaceSeparator,
+// the keys of such a namespace would be counted in the namespace of the name before the separator
+func (lc *LocalCache) Namespace(name string) *Namespace {
+	if strings.Contains(name, NamespaceSeparator) {
+		panic("go_fast_cache: namespace name " + name + " contains " + NamespaceSeparator)
+	}
+	lc.nsLock.RLock()
+	ns, exist := lc.namespaces[name]
+	lc.nsLock.RUnlock()
+	if exist {
+		return ns
+	}
+
+	lc.nsLock.Lock()
+	defer lc.nsLock.Unlock()
+	if ns, exist = lc.namespaces[name]; exist {
+		return ns
+	}
+	ns = &Namespace{
+		lc:     lc,
+		name:   name,
+		prefix: name + NamespaceSeparator,
+	}
+	//count keys set before the namespace is created
+	lc.s.Range(func(key string, e sortedset.Element) bool {
+		if strings.HasPrefix(key, ns.prefix) {
+			ns.len++
+		}
+		return true
+	})
+	lc.namespaces[name] = ns
+	atomic.AddInt32(&lc.nsCount, 1)
+	return ns
+}
+
+// namespaceOf returns the namespace of key, nil if key is not in a namespace
+func (lc *LocalCache) namespaceOf(key string) *Namespace {
+	if atomic.LoadInt32(&lc.nsCount) == 0 {
+		return nil
+	}
+	i := strings.Index(key, NamespaceSeparator)
+	if i < 0 {
+		return nil
+	}
+	lc.nsLock.RLock()
+	ns := lc.namespaces[key[:i]]
+	lc.nsLock.RUnlock()
+	return ns
+}
+
+func (lc *LocalCache) namespaceAdded(key string) {
+	if ns := lc.namespaceOf(key); ns != nil {
+		atomic.AddInt64(&ns.len, 1)
+	}
+}
+
+func (lc *LocalCache) namespaceDeleted(key string) {
+	if ns := lc.namespaceOf(key); ns != nil {
+		atomic.AddInt64(&ns.len, -1)
+	}
+}
+
+// Name returns the name of the namespace
+func (ns *Namespace) Name() string {
+	return ns.name
+}
+
+// SetQuota limits the key count of the namespace, Set of a new key is rejected when the quota is reached. 0 means no quota
+func (ns *Namespace) SetQuota(quota int64) {
+	if quota < 0 {
+		quota = 0
+	}
+	atomic.StoreInt64(&ns.quota, quota)
+}
+
+func (ns *Namespace) Get(key string) (value interface{}, ttl int64, exist bool) {
+	value, ttl, exist = ns.lc.Get(ns.prefix + key)
+	if exist {
+		atomic.AddInt64(&ns.hits, 1)
+	} else {
+		atomic.AddInt64(&ns.misses, 1)
+	}
+	return value, ttl, exist
+}
+
+// Set Set key value like LocalCache.Set, returns false if the key is new and the quota is reached.
+// Keys set through LocalCache directly are counted but not checked against the quota
+func (ns *Namespace) Set(key string, value interface{}, ttlSecond int64) bool {
+	fullKey := ns.prefix + key
+	quota := atomic.LoadInt64(&ns.quota)
+	if quota > 0 {
+		ns.quotaLock.Lock()
+		defer ns.quotaLock.Unlock()
+	}
+	if quota > 0 && atomic.LoadInt64(&ns.len) >= quota {
+		if _, exist := ns.lc.s.Get(fullKey); !exist {
+			atomic.AddInt64(&ns.rejected, 1)
+			return false
+		}
+	}
+	ns.lc.Set(fullKey, value, ttlSecond)
+	atomic.AddInt64(&ns.sets, 1)
+	return true
+}
+
+func (ns *Namespace) Delete(key string) {
+	ns.lc.Delete(ns.prefix + key)
+	atomic.AddInt64(&ns.deletes, 1)
+}
+
+// Flush deletes all keys of the namespace, returns the count of deleted keys
+func (ns *Namespace) Flush() int64 {
+	return ns.lc.DeleteByPrefix(ns.prefix)
+}
+
+// Len returns the key count of the namespace, expired keys not yet removed are included like LocalCache.GetLen
+func (ns *Namespace) Len() int64 {
+	return atomic.LoadInt64(&ns.len)
+}
+
+func (ns *Namespace) Stats() NamespaceStats {
+	return NamespaceStats{
+		Len:      atomic.LoadInt64(&ns.len),
+		Quota:    atomic.LoadInt64(&ns.quota),
+		Hits:     atomic.LoadInt64(&ns.hits),
+		Misses:   atomic.LoadInt64(&ns.misses),
+		Sets:     atomic.LoadInt64(&ns.sets),
+		Deletes:  atomic.LoadInt64(&ns.deletes),
+		Rejected: atomic.LoadInt64(&ns.rejected),
+	}
+}
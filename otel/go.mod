@@ -0,0 +1,21 @@
+module github.com/daqnext/go-fast-cache/otel
+
+go 1.20
+
+require (
+	github.com/daqnext/go-fast-cache v0.0.0-00010101000000-000000000000
+	go.opentelemetry.io/otel v1.19.0
+	go.opentelemetry.io/otel/metric v1.19.0
+	go.opentelemetry.io/otel/sdk v1.19.0
+	go.opentelemetry.io/otel/sdk/metric v1.19.0
+	go.opentelemetry.io/otel/trace v1.19.0
+)
+
+require (
+	github.com/go-logr/logr v1.2.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+)
+
+// the replace builds against the local go-fast-cache, a release requires the tagged version, see README nested modules
+replace github.com/daqnext/go-fast-cache => ..
@@ -0,0 +1,195 @@
+package otel
+
+import (
+	"context"
+	localcache "github.com/daqnext/go-fast-cache"
+	otelapi "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"sync/atomic"
+	"time"
+)
+
+// InstrumentationName is the name of the tracer and the meter
+const InstrumentationName = "github.com/daqnext/go-fast-cache/otel"
+
+// Config of the instrumentation, nil providers use the global ones
+type Config struct {
+	TracerProvider trace.TracerProvider
+	MeterProvider  metric.MeterProvider
+	// RecordKeys adds the cache.key attribute to the spans, keys may be sensitive or of high cardinality
+	RecordKeys bool
+}
+
+// Cache wraps a LocalCache with spans for every call, and records metrics of all operations of the LocalCache
+type Cache struct {
+	lc         *localcache.LocalCache
+	tracer     trace.Tracer
+	recordKeys bool
+
+	hits      int64
+	misses    int64
+	hitCount  metric.Int64Counter
+	missCount metric.Int64Counter
+	loadTime  metric.Float64Histogram
+	setErrors metric.Int64Counter
+}
+
+// New instruments lc, it registers a hook on lc so it should be called once per LocalCache
+func New(lc *localcache.LocalCache, config Config) (*Cache, error) {
+	tp := config.TracerProvider
+	if tp == nil {
+		tp = otelapi.GetTracerProvider()
+	}
+	mp := config.MeterProvider
+	if mp == nil {
+		mp = otelapi.GetMeterProvider()
+	}
+	c := &Cache{
+		lc:         lc,
+		tracer:     tp.Tracer(InstrumentationName),
+		recordKeys: config.RecordKeys,
+	}
+	if err := c.initMetrics(mp.Meter(InstrumentationName)); err != nil {
+		return nil, err
+	}
+	lc.AddHook(hook{c})
+	return c, nil
+}
+
+func (c *Cache) initMetrics(meter metric.Meter) error {
+	var err error
+	if c.hitCount, err = meter.Int64Counter("cache.hits", metric.WithDescription("Lookups which found the key")); err != nil {
+		return err
+	}
+	if c.missCount, err = meter.Int64Counter("cache.misses", metric.WithDescription("Lookups which did not find the key")); err != nil {
+		return err
+	}
+	if c.loadTime, err = meter.Float64Histogram("cache.load.duration", metric.WithDescription("Duration of GetOrLoad loaders"), metric.WithUnit("s")); err != nil {
+		return err
+	}
+	if c.setErrors, err = meter.Int64Counter("cache.set.errors", metric.WithDescription("Writes rejected by the cache")); err != nil {
+		return err
+	}
+	size, err := meter.Int64ObservableGauge("cache.size", metric.WithDescription("Count of keys"))
+	if err != nil {
+		return err
+	}
+	ratio, err := meter.Float64ObservableGauge("cache.hit_ratio", metric.WithDescription("Hits over lookups since start"))
+	if err != nil {
+		return err
+	}
+	evictions, err := meter.Int64ObservableCounter("cache.evictions", metric.WithDescription("Keys evicted over the count limit"))
+	if err != nil {
+		return err
+	}
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		o.ObserveInt64(size, c.lc.GetLen())
+		hits, misses := atomic.LoadInt64(&c.hits), atomic.LoadInt64(&c.misses)
+		if hits+misses > 0 {
+			o.ObserveFloat64(ratio, float64(hits)/float64(hits+misses))
+		}
+		o.ObserveInt64(evictions, c.lc.GetEvictionStats().Evicted)
+		return nil
+	}, size, ratio, evictions)
+	return err
+}
+
+// Local returns the wrapped LocalCache
+func (c *Cache) Local() *localcache.LocalCache {
+	return c.lc
+}
+
+// spanKey marks the ctx of a span started by Cache, the hook annotates only these spans and not the spans of other callers of the LocalCache
+type spanKey struct{}
+
+func (c *Cache) start(ctx context.Context, name string, key string) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindInternal)}
+	if c.recordKeys {
+		opts = append(opts, trace.WithAttributes(attribute.String("cache.key", key)))
+	}
+	ctx, span := c.tracer.Start(ctx, name, opts...)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// cacheSpan returns the span Cache started for the operation of ctx, nil if there is none
+func cacheSpan(ctx context.Context) trace.Span {
+	span, _ := ctx.Value(spanKey{}).(trace.Span)
+	return span
+}
+
+// Get is LocalCache.GetCtx in a cache.get span
+func (c *Cache) Get(ctx context.Context, key string) (value interface{}, ttl int64, exist bool) {
+	ctx, span := c.start(ctx, "cache.get", key)
+	defer span.End()
+	return c.lc.GetCtx(ctx, key)
+}
+
+// Set is LocalCache.SetCtx in a cache.set span
+func (c *Cache) Set(ctx context.Context, key string, value interface{}, ttlSecond int64) error {
+	ctx, span := c.start(ctx, "cache.set", key)
+	defer span.End()
+	err := c.lc.SetCtx(ctx, key, value, ttlSecond)
+	recordError(span, err)
+	return err
+}
+
+// GetOrLoad is LocalCache.GetOrLoadCtx in a cache.load span, spans started by loader with its ctx are children of it
+func (c *Cache) GetOrLoad(ctx context.Context, key string, ttlSecond int64, loader func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	ctx, span := c.start(ctx, "cache.load", key)
+	defer span.End()
+	value, err := c.lc.GetOrLoadCtx(ctx, key, ttlSecond, func(ctx context.Context) (interface{}, error) {
+		//the calls of loader on the LocalCache are not part of this operation
+		return loader(context.WithValue(ctx, spanKey{}, nil))
+	})
+	recordError(span, err)
+	return value, err
+}
+
+// Delete is LocalCache.DeleteCtx in a cache.delete span
+func (c *Cache) Delete(ctx context.Context, key string) {
+	ctx, span := c.start(ctx, "cache.delete", key)
+	defer span.End()
+	c.lc.DeleteCtx(ctx, key)
+}
+
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// hook records the metrics of every operation, and the hit attribute on the span Cache started for the operation
+type hook struct {
+	c *Cache
+}
+
+func (h hook) OnGet(ctx context.Context, key string, hit bool) {
+	if hit {
+		atomic.AddInt64(&h.c.hits, 1)
+		h.c.hitCount.Add(ctx, 1)
+	} else {
+		atomic.AddInt64(&h.c.misses, 1)
+		h.c.missCount.Add(ctx, 1)
+	}
+	if span := cacheSpan(ctx); span != nil {
+		span.SetAttributes(attribute.Bool("cache.hit", hit))
+	}
+}
+
+func (h hook) OnSet(ctx context.Context, key string, err error) {
+	if err != nil {
+		h.c.setErrors.Add(ctx, 1)
+	}
+}
+
+func (h hook) OnLoad(ctx context.Context, key string, duration time.Duration, err error) {
+	h.c.loadTime.Record(ctx, duration.Seconds(), metric.WithAttributes(attribute.Bool("error", err != nil)))
+	if span := cacheSpan(ctx); span != nil {
+		span.AddEvent("loaded", trace.WithAttributes(attribute.Float64("cache.load.duration", duration.Seconds())))
+	}
+}
@@ -0,0 +1,145 @@
+package otel_test
+
+import (
+	"context"
+	"errors"
+	localcache "github.com/daqnext/go-fast-cache"
+	cacheotel "github.com/daqnext/go-fast-cache/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func spanBool(span sdktrace.ReadOnlySpan, key attribute.Key) (value bool, ok bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value.AsBool(), true
+		}
+	}
+	return false, false
+}
+
+func Test_OtelSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	lc := localcache.New(nil)
+	c, err := cacheotel.New(lc, cacheotel.Config{TracerProvider: tp, MeterProvider: sdkmetric.NewMeterProvider()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	c.Set(ctx, "a", 1, 10)
+	c.Get(ctx, "a")
+	c.Get(ctx, "b")
+	c.GetOrLoad(ctx, "c", 10, func(ctx context.Context) (interface{}, error) {
+		return 3, nil
+	})
+	c.GetOrLoad(ctx, "d", 10, func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("backend down")
+	})
+
+	spans := recorder.Ended()
+	names := []string{"cache.set", "cache.get", "cache.get", "cache.load", "cache.load"}
+	hits := []bool{false, true, false, false, false}
+	if len(spans) != len(names) {
+		t.Fatalf("%d spans", len(spans))
+	}
+	for i, span := range spans {
+		if span.Name() != names[i] {
+			t.Fatalf("span %d name %s", i, span.Name())
+		}
+		if i == 0 {
+			continue
+		}
+		if hit, ok := spanBool(span, "cache.hit"); !ok || hit != hits[i] {
+			t.Fatalf("span %d hit %v %v", i, hit, ok)
+		}
+	}
+	if spans[4].Status().Code != codes.Error {
+		t.Fatalf("load error status %+v", spans[4].Status())
+	}
+}
+
+func Test_OtelOtherSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	lc := localcache.New(nil)
+	c, err := cacheotel.New(lc, cacheotel.Config{TracerProvider: tp, MeterProvider: sdkmetric.NewMeterProvider()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the span of a caller of the LocalCache is not annotated
+	ctx, span := tp.Tracer("app").Start(context.Background(), "request")
+	lc.GetCtx(ctx, "a")
+	lc.GetOrLoadCtx(ctx, "b", 10, func(ctx context.Context) (interface{}, error) {
+		return 2, nil
+	})
+	span.End()
+	//the Gets of the loader do not overwrite the hit of cache.load
+	lc.Set("hit", 1, 10)
+	c.GetOrLoad(context.Background(), "c", 10, func(ctx context.Context) (interface{}, error) {
+		lc.GetCtx(ctx, "hit")
+		return 3, nil
+	})
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("%d spans", len(spans))
+	}
+	if _, ok := spanBool(spans[0], "cache.hit"); ok || len(spans[0].Events()) != 0 {
+		t.Fatalf("request span annotated %v %v", spans[0].Attributes(), spans[0].Events())
+	}
+	if hit, ok := spanBool(spans[1], "cache.hit"); !ok || hit {
+		t.Fatalf("load span hit %v %v", hit, ok)
+	}
+	if len(spans[1].Events()) != 1 {
+		t.Fatalf("load span events %v", spans[1].Events())
+	}
+}
+
+func Test_OtelMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	lc := localcache.New(nil)
+	if _, err := cacheotel.New(lc, cacheotel.Config{MeterProvider: mp}); err != nil {
+		t.Fatal(err)
+	}
+	//metrics cover calls on the LocalCache itself too
+	lc.Set("a", 1, 10)
+	lc.Get("a")
+	lc.Get("a")
+	lc.Get("a")
+	lc.Get("b")
+
+	rm := metricdata.ResourceMetrics{}
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			got[m.Name] = m.Data
+		}
+	}
+	if sum := got["cache.hits"].(metricdata.Sum[int64]); sum.DataPoints[0].Value != 3 {
+		t.Fatalf("hits %+v", sum)
+	}
+	if sum := got["cache.misses"].(metricdata.Sum[int64]); sum.DataPoints[0].Value != 1 {
+		t.Fatalf("misses %+v", sum)
+	}
+	if gauge := got["cache.size"].(metricdata.Gauge[int64]); gauge.DataPoints[0].Value != 1 {
+		t.Fatalf("size %+v", gauge)
+	}
+	if gauge := got["cache.hit_ratio"].(metricdata.Gauge[float64]); gauge.DataPoints[0].Value != 0.75 {
+		t.Fatalf("hit ratio %+v", gauge)
+	}
+	if _, ok := got["cache.evictions"]; !ok {
+		t.Fatal("evictions missing")
+	}
+}
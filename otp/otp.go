@@ -0,0 +1,210 @@
+package otp
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	localcache "github.com/daqnext/go-fast-cache"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultLength               = 6
+	DefaultMaxAttempts          = 5
+	DefaultResendCooldownSecond = 60
+	DefaultLockoutSecond        = 900
+	DefaultKeyPrefix            = "otp:"
+
+	lockCount = 64
+)
+
+var (
+	// ErrNotFound is returned by Verify when no code is issued for the subject, or it has expired or been used
+	ErrNotFound = errors.New("otp: no valid code")
+	// ErrMismatch is returned by Verify for a wrong code while attempts are left
+	ErrMismatch = errors.New("otp: wrong code")
+	// ErrLocked is returned when the subject is locked out after too many wrong codes
+	ErrLocked = errors.New("otp: too many failed attempts")
+	// ErrCooldown is returned by Issue when the last code of the subject was issued less than the resend cooldown ago
+	ErrCooldown = errors.New("otp: resend is cooling down")
+	// ErrTTL is returned by Issue for a ttl which is not positive
+	ErrTTL = errors.New("otp: ttl must be positive")
+)
+
+// Config of a Manager, zero values use the defaults
+type Config struct {
+	Length   int    // length of the codes, DefaultLength
+	Alphabet string // alphabet of the codes, localcache.AlphabetDigits
+	// MaxAttempts is the count of wrong codes which locks the subject out, DefaultMaxAttempts
+	MaxAttempts int
+	// ResendCooldownSecond is the min interval between two codes of a subject, DefaultResendCooldownSecond, negative disables it. At most localcache.MaxTTLSecond
+	ResendCooldownSecond int64
+	// LockoutSecond is how long a subject is locked out after MaxAttempts wrong codes, DefaultLockoutSecond. At most localcache.MaxTTLSecond
+	LockoutSecond int64
+	// KeyPrefix of the cache keys of the subjects, DefaultKeyPrefix
+	KeyPrefix string
+}
+
+// state of a subject, stored as one cache value and replaced as a whole under the lock of the subject
+type state struct {
+	code         string // "" after it is used or the subject is locked
+	codeExpireAt int64
+	issuedAt     int64
+	attempts     int
+	lockedUntil  int64
+}
+
+// Manager issues and verifies one-time codes on a LocalCache. Issue and Verify of a subject are atomic with respect to each other
+type Manager struct {
+	lc     *localcache.LocalCache
+	config Config
+	locks  [lockCount]sync.Mutex
+}
+
+// New returns a Manager storing the subjects in lc, through the middleware chain of lc for every access. TTL jitter of lc may end a lockout or cooldown early, leave it off on a cache used for codes
+func New(lc *localcache.LocalCache, config Config) *Manager {
+	if config.Length <= 0 {
+		config.Length = DefaultLength
+	}
+	if config.Alphabet == "" {
+		config.Alphabet = localcache.AlphabetDigits
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+	if config.ResendCooldownSecond == 0 {
+		config.ResendCooldownSecond = DefaultResendCooldownSecond
+	}
+	if config.ResendCooldownSecond < 0 {
+		config.ResendCooldownSecond = 0
+	}
+	if config.LockoutSecond <= 0 {
+		config.LockoutSecond = DefaultLockoutSecond
+	}
+	//the state of a subject can not outlive the max ttl of the cache
+	if config.ResendCooldownSecond > localcache.MaxTTLSecond {
+		config.ResendCooldownSecond = localcache.MaxTTLSecond
+	}
+	if config.LockoutSecond > localcache.MaxTTLSecond {
+		config.LockoutSecond = localcache.MaxTTLSecond
+	}
+	if config.KeyPrefix == "" {
+		config.KeyPrefix = DefaultKeyPrefix
+	}
+	return &Manager{lc: lc, config: config}
+}
+
+// Issue makes a new code for subject valid for ttlSecond, it replaces the unused code of the subject and resets its attempts.
+// Returns ErrCooldown within the resend cooldown and ErrLocked while the subject is locked out
+func (m *Manager) Issue(subject string, ttlSecond int64) (string, error) {
+	if ttlSecond <= 0 {
+		return "", ErrTTL
+	}
+	if ttlSecond > localcache.MaxTTLSecond {
+		ttlSecond = localcache.MaxTTLSecond
+	}
+	lock := m.lock(subject)
+	lock.Lock()
+	defer lock.Unlock()
+
+	now := time.Now().Unix()
+	st, _ := m.get(subject)
+	if st.lockedUntil > now {
+		return "", ErrLocked
+	}
+	if st.issuedAt > 0 && st.issuedAt+m.config.ResendCooldownSecond > now {
+		return "", ErrCooldown
+	}
+	code, err := localcache.RandString(m.config.Length, m.config.Alphabet)
+	if err != nil {
+		return "", err
+	}
+	err = m.set(subject, state{code: code, codeExpireAt: now + ttlSecond, issuedAt: now}, now)
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// Verify checks code against the code of subject in constant time. A right code is used up, a wrong one counts an attempt and the MaxAttempts-th wrong code locks the subject out.
+// Returns nil if code is right, ErrMismatch, ErrLocked or ErrNotFound otherwise, or the write error of the cache, then the code is not accepted
+func (m *Manager) Verify(subject string, code string) error {
+	lock := m.lock(subject)
+	lock.Lock()
+	defer lock.Unlock()
+
+	now := time.Now().Unix()
+	st, exist := m.get(subject)
+	if !exist {
+		return ErrNotFound
+	}
+	if st.lockedUntil > now {
+		return ErrLocked
+	}
+	if st.code == "" || st.codeExpireAt <= now {
+		return ErrNotFound
+	}
+	if subtle.ConstantTimeCompare([]byte(st.code), []byte(code)) == 1 {
+		//keep the issue time for the resend cooldown
+		st.code = ""
+		return m.set(subject, st, now)
+	}
+	st.attempts++
+	if st.attempts >= m.config.MaxAttempts {
+		st.code = ""
+		st.lockedUntil = now + m.config.LockoutSecond
+		if err := m.set(subject, st, now); err != nil {
+			return err
+		}
+		return ErrLocked
+	}
+	if err := m.set(subject, st, now); err != nil {
+		return err
+	}
+	return ErrMismatch
+}
+
+// Revoke removes the code, attempts, cooldown and lockout of subject
+func (m *Manager) Revoke(subject string) {
+	lock := m.lock(subject)
+	lock.Lock()
+	m.lc.DeleteCtx(context.Background(), m.config.KeyPrefix+subject)
+	lock.Unlock()
+}
+
+// lock returns the lock of subject, fnv-1a hash
+func (m *Manager) lock(subject string) *sync.Mutex {
+	hash := uint32(2166136261)
+	for i := 0; i < len(subject); i++ {
+		hash ^= uint32(subject[i])
+		hash *= 16777619
+	}
+	return &m.locks[hash%lockCount]
+}
+
+// get reads the state of subject
+func (m *Manager) get(subject string) (state, bool) {
+	v, _, exist := m.lc.GetCtx(context.Background(), m.config.KeyPrefix+subject)
+	if !exist {
+		return state{}, false
+	}
+	st, ok := v.(state)
+	return st, ok
+}
+
+// set stores the state of subject until its code, cooldown and lockout are all over
+func (m *Manager) set(subject string, st state, now int64) error {
+	expireAt := st.issuedAt + m.config.ResendCooldownSecond
+	if st.code != "" && st.codeExpireAt > expireAt {
+		expireAt = st.codeExpireAt
+	}
+	if st.lockedUntil > expireAt {
+		expireAt = st.lockedUntil
+	}
+	if expireAt <= now {
+		m.lc.DeleteCtx(context.Background(), m.config.KeyPrefix+subject)
+		return nil
+	}
+	return m.lc.SetContext(context.Background(), m.config.KeyPrefix+subject, st, expireAt-now)
+}
@@ -1,16 +1,122 @@
 package go_fast_cache
 
-import "math/rand"
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"github.com/daqnext/go-fast-cache/sortedset"
+	"reflect"
+	"time"
+)
 
-const letterBytes = "abcdefghijklmnopqrstuvwxyz"
+const (
+	// AlphabetLetters is the default alphabet of SetRand
+	AlphabetLetters = "abcdefghijklmnopqrstuvwxyz"
+	AlphabetDigits  = "0123456789"
+	// AlphabetBase32 is the RFC 4648 base32 alphabet
+	AlphabetBase32 = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"
+	// AlphabetURLSafe is the RFC 4648 base64url alphabet
+	AlphabetURLSafe = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
 
-func genRandStr(n int) string {
-	if n <= 0 {
+	DefaultRandLength = 20
+	MaxRandLength     = 1024
+)
+
+type randFormat struct {
+	length   int
+	alphabet string
+}
+
+// SetRandFormat sets the length and alphabet of the tokens made by SetRand, default is DefaultRandLength and AlphabetLetters.
+// A length out of [1, MaxRandLength] uses the default length, an alphabet with less than 2 or more than 256 bytes uses AlphabetLetters
+func (lc *LocalCache) SetRandFormat(length int, alphabet string) {
+	if length < 1 || length > MaxRandLength {
+		length = DefaultRandLength
+	}
+	if len(alphabet) < 2 || len(alphabet) > 256 {
+		alphabet = AlphabetLetters
+	}
+	lc.randFormat.Store(randFormat{length: length, alphabet: alphabet})
+}
+
+func (lc *LocalCache) getRandFormat() randFormat {
+	if f, ok := lc.randFormat.Load().(randFormat); ok {
+		return f
+	}
+	return randFormat{length: DefaultRandLength, alphabet: AlphabetLetters}
+}
+
+// SetRand sets a token made by crypto/rand in the format of SetRandFormat with ttlSecond, and returns it.
+// Returns "" without setting the key if the system random source fails
+func (lc *LocalCache) SetRand(key string, ttlSecond int64) string {
+	f := lc.getRandFormat()
+	rs, err := RandString(f.length, f.alphabet)
+	if err != nil {
+		lc.llog.Errorf("SetRand %s: %v", key, err)
 		return ""
 	}
+	lc.Set(key, rs, ttlSecond)
+	return rs
+}
+
+func (lc *LocalCache) GetRand(key string) string {
+	v, _, exist := lc.Get(key)
+	if !exist {
+		return ""
+	}
+	return v.(string)
+}
+
+// VerifyRand compares token with the unexpired token of key in constant time, and deletes key if they are equal, so a token is verified once at most under concurrency.
+// Like SetRand and GetRand it reads and deletes through the middleware chain, the delete is done only if the stored value is still the one read
+func (lc *LocalCache) VerifyRand(key string, token string) bool {
+	if token == "" {
+		return false
+	}
+	read := &coreOp{}
+	value, _, exist := lc.handler().Get(withOp(context.Background(), read), key)
+	stored, ok := value.(string)
+	if !exist || !ok || read.found == nil || subtle.ConstantTimeCompare([]byte(stored), []byte(token)) != 1 {
+		return false
+	}
+	found := read.found.Value
+	del := &coreOp{cond: func(e sortedset.Element) bool {
+		return e.Score > time.Now().Unix() && reflect.DeepEqual(e.Value, found)
+	}}
+	lc.handler().Delete(withOp(context.Background(), del), key)
+	return del.deleted
+}
+
+// ErrRandAlphabet is returned by RandString for an alphabet without 1 to 256 bytes
+var ErrRandAlphabet = errors.New("alphabet must have 1 to 256 bytes")
+
+// RandString returns n bytes of alphabet chosen by crypto/rand, bytes which would bias the choice are rejected
+func RandString(n int, alphabet string) (string, error) {
+	if len(alphabet) == 0 || len(alphabet) > 256 {
+		return "", ErrRandAlphabet
+	}
+	if n <= 0 {
+		return "", nil
+	}
+	size := len(alphabet)
+	limit := 256 - 256%size
 	b := make([]byte, n)
-	for i := range b {
-		b[i] = letterBytes[rand.Int63()%int64(len(letterBytes))]
+	buf := make([]byte, n+n/4+8)
+	for i := 0; i < n; {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, r := range buf {
+			if int(r) >= limit {
+				continue
+			}
+			b[i] = alphabet[int(r)%size]
+			i++
+			if i == n {
+				break
+			}
+		}
 	}
-	return string(b)
+	return string(b), nil
 }
@@ -0,0 +1,74 @@
+package resp
+
+// globMatch reports whether key matches the redis glob pattern: * ? [abc] [^a-z] and \ escapes. Unlike path.Match, / is not special
+func globMatch(pattern string, key string) bool {
+	p, k := 0, 0
+	//position after the last * in pattern and the key position it is matched from, to backtrack on a mismatch
+	star, next := -1, 0
+	for k < len(key) {
+		if p < len(pattern) && pattern[p] == '*' {
+			p++
+			star, next = p, k
+			continue
+		}
+		if p < len(pattern) {
+			if n, ok := matchByte(pattern[p:], key[k]); ok {
+				p += n
+				k++
+				continue
+			}
+		}
+		if star < 0 {
+			return false
+		}
+		next++
+		p, k = star, next
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// matchByte matches c against the first token of pattern, which is not *, and returns the length of the token
+func matchByte(pattern string, c byte) (int, bool) {
+	switch pattern[0] {
+	case '?':
+		return 1, true
+	case '\\':
+		if len(pattern) > 1 {
+			return 2, pattern[1] == c
+		}
+		return 1, c == '\\'
+	case '[':
+		i := 1
+		not := i < len(pattern) && pattern[i] == '^'
+		if not {
+			i++
+		}
+		matched := false
+		//like redis, a class without ] ends at the end of pattern
+		for i < len(pattern) && pattern[i] != ']' {
+			switch {
+			case pattern[i] == '\\' && i+1 < len(pattern):
+				matched = matched || pattern[i+1] == c
+				i += 2
+			case i+2 < len(pattern) && pattern[i+1] == '-':
+				lo, hi := pattern[i], pattern[i+2]
+				if lo > hi {
+					lo, hi = hi, lo
+				}
+				matched = matched || (c >= lo && c <= hi)
+				i += 3
+			default:
+				matched = matched || pattern[i] == c
+				i++
+			}
+		}
+		if i < len(pattern) {
+			i++
+		}
+		return i, matched != not
+	}
+	return 1, pattern[0] == c
+}
@@ -0,0 +1,312 @@
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	localcache "github.com/daqnext/go-fast-cache"
+	"github.com/daqnext/go-fast-cache/logging"
+	"github.com/daqnext/go-fast-cache/ttltype"
+	"net"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Server serves a LocalCache over the Redis RESP2 protocol.
+// Supported commands: GET SET(EX) DEL TTL EXPIRE INCR KEYS DBSIZE PING.
+// Like redis, keys set without EX and keys created by INCR never expire, but they are still evicted when the key count is over limit
+type Server struct {
+	lc   *localcache.LocalCache
+	llog logging.Logger
+
+	// lock makes the writes of clients atomic among each other
+	lock     sync.Mutex
+	connLock sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+// ErrServerClosed is returned by Serve after Close
+var ErrServerClosed = errors.New("resp: server closed")
+
+// NewServer returns a server for lc, values set by clients are stored as string. A panic serving a client is logged to logger, nil logs nothing
+func NewServer(lc *localcache.LocalCache, logger logging.Logger) *Server {
+	return &Server{
+		lc:    lc,
+		llog:  logging.OrNop(logger),
+		conns: make(map[net.Conn]struct{}),
+	}
+}
+
+// ListenAndServe listens on the TCP address addr and serves clients
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l until Close is called
+func (s *Server) Serve(l net.Listener) error {
+	s.connLock.Lock()
+	if s.closed {
+		s.connLock.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listener = l
+	s.connLock.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.connLock.Lock()
+			closed := s.closed
+			s.connLock.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+		s.connLock.Lock()
+		s.conns[conn] = struct{}{}
+		s.connLock.Unlock()
+		go s.handleConn(conn)
+	}
+}
+
+// Close stops the listener and closes all client connections
+func (s *Server) Close() error {
+	s.connLock.Lock()
+	defer s.connLock.Unlock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	if s.listener != nil {
+		return s.listener.Close()
+	}
+	return nil
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer func() {
+		//a command must not crash the server, the client is disconnected
+		if r := recover(); r != nil {
+			s.llog.Errorf("resp: panic serving %s: %v\n%s", conn.RemoteAddr(), r, debug.Stack())
+		}
+		conn.Close()
+		s.connLock.Lock()
+		delete(s.conns, conn)
+		s.connLock.Unlock()
+	}()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if err == errProtocol {
+				writeError(w, err.Error())
+				w.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if strings.ToUpper(args[0]) == "QUIT" {
+			writeSimple(w, "OK")
+			w.Flush()
+			return
+		}
+		s.exec(w, args)
+		//flush when no pipelined command is waiting
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) exec(w *bufio.Writer, args []string) {
+	cmd := strings.ToUpper(args[0])
+	switch cmd {
+	case "PING":
+		if len(args) > 2 {
+			writeArgCountError(w, cmd)
+		} else if len(args) == 2 {
+			writeBulk(w, args[1])
+		} else {
+			writeSimple(w, "PONG")
+		}
+	case "GET":
+		if len(args) != 2 {
+			writeArgCountError(w, cmd)
+			return
+		}
+		value, exist, err := s.getString(args[1])
+		if err != nil {
+			writeError(w, err.Error())
+		} else if !exist {
+			writeNil(w)
+		} else {
+			writeBulk(w, value)
+		}
+	case "SET":
+		s.set(w, args)
+	case "DEL":
+		if len(args) < 2 {
+			writeArgCountError(w, cmd)
+			return
+		}
+		s.lock.Lock()
+		count := int64(0)
+		for _, key := range args[1:] {
+			if _, _, exist := s.lc.Get(key); exist {
+				s.lc.Delete(key)
+				count++
+			}
+		}
+		s.lock.Unlock()
+		writeInt(w, count)
+	case "TTL":
+		if len(args) != 2 {
+			writeArgCountError(w, cmd)
+			return
+		}
+		ttl, exist := s.lc.TTL(args[1])
+		if !exist {
+			writeInt(w, -2)
+			return
+		}
+		writeInt(w, ttl)
+	case "EXPIRE":
+		s.expire(w, args)
+	case "INCR":
+		if len(args) != 2 {
+			writeArgCountError(w, cmd)
+			return
+		}
+		s.incr(w, args[1])
+	case "KEYS":
+		if len(args) != 2 {
+			writeArgCountError(w, cmd)
+			return
+		}
+		keys := make([]string, 0)
+		s.lc.Range(func(key string, value interface{}, ttl int64) bool {
+			if globMatch(args[1], key) {
+				keys = append(keys, key)
+			}
+			return true
+		})
+		writeArray(w, keys)
+	case "DBSIZE":
+		writeInt(w, s.lc.GetLen())
+	default:
+		writeError(w, fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+}
+
+// SET key value [EX seconds]
+func (s *Server) set(w *bufio.Writer, args []string) {
+	if len(args) != 3 && len(args) != 5 {
+		writeError(w, "ERR syntax error")
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(args) == 3 {
+		s.setPersist(args[1], args[2])
+		writeSimple(w, "OK")
+		return
+	}
+	if strings.ToUpper(args[3]) != "EX" {
+		writeError(w, "ERR syntax error")
+		return
+	}
+	seconds, err := strconv.ParseInt(args[4], 10, 64)
+	if err != nil || seconds <= 0 {
+		writeError(w, "ERR invalid expire time in 'set' command")
+		return
+	}
+	s.lc.Set(args[1], args[2], seconds)
+	writeSimple(w, "OK")
+}
+
+// setPersist sets key value without expire time, s.lock must be held so no other write of a client comes between Set and Persist
+func (s *Server) setPersist(key string, value string) {
+	s.lc.Set(key, value, localcache.MaxTTLSecond)
+	s.lc.Persist(key)
+}
+
+// EXPIRE key seconds
+func (s *Server) expire(w *bufio.Writer, args []string) {
+	if len(args) != 3 {
+		writeArgCountError(w, "EXPIRE")
+		return
+	}
+	seconds, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		writeError(w, "ERR value is not an integer or out of range")
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.lc.Expire(args[1], seconds) {
+		writeInt(w, 1)
+	} else {
+		writeInt(w, 0)
+	}
+}
+
+func (s *Server) incr(w *bufio.Writer, key string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	value, exist, err := s.getString(key)
+	if err != nil {
+		writeError(w, err.Error())
+		return
+	}
+	n := int64(0)
+	if exist {
+		n, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			writeError(w, "ERR value is not an integer or out of range")
+			return
+		}
+	}
+	n++
+	if exist {
+		//keep the ttl left
+		s.lc.Set(key, strconv.FormatInt(n, 10), ttltype.Keep)
+	} else {
+		s.setPersist(key, strconv.FormatInt(n, 10))
+	}
+	writeInt(w, n)
+}
+
+func (s *Server) getString(key string) (string, bool, error) {
+	value, _, exist := s.lc.Get(key)
+	if !exist {
+		return "", false, nil
+	}
+	switch v := value.(type) {
+	case string:
+		return v, true, nil
+	case []byte:
+		return string(v), true, nil
+	default:
+		return "", false, errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+	}
+}
+
+func writeArgCountError(w *bufio.Writer, cmd string) {
+	writeError(w, fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(cmd)))
+}
@@ -0,0 +1,74 @@
+package go_fast_cache
+
+import (
+	"context"
+	"github.com/daqnext/go-fast-cache/sortedset"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// SlideIntervalMillisecond is the interval of the job which applies the expire time extended by reads
+	SlideIntervalMillisecond = 500
+	// slideStepRate of the ttl must have passed before a read extends the expire time again
+	slideStepRate = 0.1
+)
+
+// SetSlidingExpiration enables sliding expiration of all keys: Get resets the expire time to now + the ttl the key was set with
+func (lc *LocalCache) SetSlidingExpiration(enable bool) {
+	if enable {
+		atomic.StoreInt32(&lc.sliding, 1)
+	} else {
+		atomic.StoreInt32(&lc.sliding, 0)
+	}
+}
+
+// SetSliding Set key value like Set, Get of the key resets its expire time to now + ttlSecond
+func (lc *LocalCache) SetSliding(key string, value interface{}, ttlSecond int64) {
+	lc.SetContext(withOp(context.Background(), &coreOp{sliding: true}), key, value, ttlSecond)
+}
+
+// slide queues key to extend its expire time. To keep Get cheap, a key is queued only after slideStepRate of its ttl has passed,
+// and the queue is applied to the sortedset by scheduleSlide in batch
+func (lc *LocalCache) slide(key string, e sortedset.Element, nowTime int64) {
+	if e.Score == persistScore {
+		return
+	}
+	step := int64(float64(e.TTL) * slideStepRate)
+	if step < 1 {
+		step = 1
+	}
+	if nowTime+e.TTL-e.Score < step {
+		return
+	}
+	lc.slideLock.Lock()
+	lc.slidePending[key] = struct{}{}
+	lc.slideLock.Unlock()
+}
+
+func (lc *LocalCache) scheduleSlide() {
+	lc.sup.Go("scheduleSlide", func() {
+		for {
+			time.Sleep(SlideIntervalMillisecond * time.Millisecond)
+			lc.slideLock.Lock()
+			if len(lc.slidePending) == 0 {
+				lc.slideLock.Unlock()
+				continue
+			}
+			pending := lc.slidePending
+			lc.slidePending = make(map[string]struct{})
+			lc.slideLock.Unlock()
+
+			nowTime := time.Now().Unix()
+			for key := range pending {
+				lc.s.Update(key, func(e *sortedset.Element) bool {
+					if e.Score <= nowTime || e.Score == persistScore {
+						return false
+					}
+					e.Score = nowTime + e.TTL
+					return true
+				})
+			}
+		}
+	})
+}
@@ -2,18 +2,23 @@ package sortedset
 
 import (
 	"math/rand"
-	"sync"
 )
 
 const (
 	maxLevel = 32
+
+	// maxFreeNodes limits the removed nodes kept for reuse
+	maxFreeNodes = 4096
 )
 
 // Element is a key-score pair
 type Element struct {
 	//Member string
-	Score int64
-	Value interface{}
+	Score   int64
+	TTL     int64 // ttl the score was computed from, kept for the cache to refresh the score
+	Sliding bool  // the cache refreshes the score on read
+	Delta   int64 // milliseconds the cache took to load the value, 0 if unknown
+	Value   interface{}
 }
 
 // Level aspect of a node
@@ -27,7 +32,13 @@ type node struct {
 	Member   string
 	Score    int64
 	backward *node
-	level    []*Level // level[0] is base level
+	level    []Level // level[0] is base level
+}
+
+// memberScore is a member removed from the skiplist
+type memberScore struct {
+	member string
+	score  int64
 }
 
 type skiplist struct {
@@ -35,21 +46,54 @@ type skiplist struct {
 	tail   *node
 	length int64
 	level  int16
-	lock   sync.Mutex
+
+	// buffers reused by insert and remove, the skiplist is only modified under the SortedSet lock
+	update [maxLevel]*node
+	rank   [maxLevel]int64
+
+	// removed nodes kept for reuse, indexed by level
+	free      [maxLevel + 1][]*node
+	freeCount int
 }
 
 func makeNode(level int16, score int64, member string) *node {
-	n := &node{
+	return &node{
 		Score:  score,
 		Member: member,
-		level:  make([]*Level, level),
+		level:  make([]Level, level),
 	}
-	for i := range n.level {
-		n.level[i] = new(Level)
+}
+
+// newNode reuses a removed node of the same level if possible
+func (skiplist *skiplist) newNode(level int16, score int64, member string) *node {
+	free := skiplist.free[level]
+	if len(free) == 0 {
+		return makeNode(level, score, member)
 	}
+	n := free[len(free)-1]
+	free[len(free)-1] = nil
+	skiplist.free[level] = free[:len(free)-1]
+	skiplist.freeCount--
+	n.Score = score
+	n.Member = member
 	return n
 }
 
+// freeNode keeps a removed node for reuse
+func (skiplist *skiplist) freeNode(n *node) {
+	if skiplist.freeCount >= maxFreeNodes {
+		return
+	}
+	n.Member = ""
+	n.backward = nil
+	for i := range n.level {
+		n.level[i] = Level{}
+	}
+	level := len(n.level)
+	skiplist.free[level] = append(skiplist.free[level], n)
+	skiplist.freeCount++
+}
+
 func makeSkiplist() *skiplist {
 	return &skiplist{
 		level:  1,
@@ -69,8 +113,8 @@ func randomLevel() int16 {
 }
 
 func (skiplist *skiplist) insert(member string, score int64) {
-	update := make([]*node, maxLevel) // link new node with node in `update`
-	rank := make([]int64, maxLevel)
+	update := &skiplist.update // link new node with node in `update`
+	rank := &skiplist.rank
 
 	// find position to insert
 	node := skiplist.header
@@ -80,14 +124,12 @@ func (skiplist *skiplist) insert(member string, score int64) {
 		} else {
 			rank[i] = rank[i+1] // store rank that is crossed to reach the insert position
 		}
-		if node.level[i] != nil {
-			// traverse the skip list
-			for node.level[i].forward != nil &&
-				(node.level[i].forward.Score < score ||
-					(node.level[i].forward.Score == score && node.level[i].forward.Member < member)) { // same score, different key
-				rank[i] += node.level[i].span
-				node = node.level[i].forward
-			}
+		// traverse the skip list
+		for node.level[i].forward != nil &&
+			(node.level[i].forward.Score < score ||
+				(node.level[i].forward.Score == score && node.level[i].forward.Member < member)) { // same score, different key
+			rank[i] += node.level[i].span
+			node = node.level[i].forward
 		}
 		update[i] = node
 	}
@@ -104,7 +146,7 @@ func (skiplist *skiplist) insert(member string, score int64) {
 	}
 
 	// make node and link into skiplist
-	node = makeNode(level, score, member)
+	node = skiplist.newNode(level, score, member)
 	for i := int16(0); i < level; i++ {
 		node.level[i].forward = update[i].level[i].forward
 		update[i].level[i].forward = node
@@ -137,7 +179,7 @@ func (skiplist *skiplist) insert(member string, score int64) {
  * param node: node to delete
  * param update: backward node (of target)
  */
-func (skiplist *skiplist) removeNode(node *node, update []*node) {
+func (skiplist *skiplist) removeNode(node *node, update *[maxLevel]*node) {
 	for i := int16(0); i < skiplist.level; i++ {
 		if update[i].level[i].forward == node {
 			update[i].level[i].span += node.level[i].span - 1
@@ -165,7 +207,7 @@ func (skiplist *skiplist) remove(member string, score int64) {
 	 * find backward node (of target) or last node of each level
 	 * their forward need to be updated
 	 */
-	update := make([]*node, maxLevel)
+	update := &skiplist.update
 	node := skiplist.header
 	for i := skiplist.level - 1; i >= 0; i-- {
 		for node.level[i].forward != nil &&
@@ -179,6 +221,7 @@ func (skiplist *skiplist) remove(member string, score int64) {
 	node = node.level[0].forward
 	if node != nil && score == node.Score && node.Member == member {
 		skiplist.removeNode(node, update)
+		skiplist.freeNode(node)
 	}
 }
 
@@ -280,11 +323,12 @@ func (skiplist *skiplist) getLastInScoreRange(min int64, max int64) *node {
 }
 
 /*
+ * param limit: max count to remove, <0 means no limit
  * return removed elements
  */
-func (skiplist *skiplist) RemoveRangeByScore(min int64, max int64) (removed []string) {
-	update := make([]*node, maxLevel)
-	removed = make([]string, 0)
+func (skiplist *skiplist) RemoveRangeByScore(min int64, max int64, limit int) (removed []memberScore) {
+	update := &skiplist.update
+	removed = make([]memberScore, 0)
 	// find backward nodes (of target range) or last node of each level
 	node := skiplist.header
 	for i := skiplist.level - 1; i >= 0; i-- {
@@ -301,24 +345,24 @@ func (skiplist *skiplist) RemoveRangeByScore(min int64, max int64) (removed []st
 	node = node.level[0].forward
 
 	// remove nodes in range
-	for node != nil {
+	for node != nil && (limit < 0 || len(removed) < limit) {
 		if max < (node.Score) { // already out of range
 			break
 		}
 		next := node.level[0].forward
-		removedElement := node.Member
-		removed = append(removed, removedElement)
+		removed = append(removed, memberScore{member: node.Member, score: node.Score})
 		skiplist.removeNode(node, update)
+		skiplist.freeNode(node)
 		node = next
 	}
 	return removed
 }
 
 // 1-based rank, including start, exclude stop
-func (skiplist *skiplist) RemoveRangeByRank(start int64, stop int64) (removed []string) {
+func (skiplist *skiplist) RemoveRangeByRank(start int64, stop int64) (removed []memberScore) {
 	var i int64 = 0 // rank of iterator
-	update := make([]*node, maxLevel)
-	removed = make([]string, 0)
+	update := &skiplist.update
+	removed = make([]memberScore, 0)
 
 	// scan from top level
 	node := skiplist.header
@@ -336,9 +380,9 @@ func (skiplist *skiplist) RemoveRangeByRank(start int64, stop int64) (removed []
 	// remove nodes in range
 	for node != nil && i < stop {
 		next := node.level[0].forward
-		removedElement := node.Member
-		removed = append(removed, removedElement)
+		removed = append(removed, memberScore{member: node.Member, score: node.Score})
 		skiplist.removeNode(node, update)
+		skiplist.freeNode(node)
 		node = next
 		i++
 	}
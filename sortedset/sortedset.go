@@ -1,113 +1,365 @@
 package sortedset
 
 import (
+	"context"
+	"errors"
+	"runtime/debug"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
+const (
+	shardCount = 256
+)
+
+type jobOp uint8
+
+const (
+	jobInsert jobOp = iota
+	jobUpdate
+	jobRemove
+)
+
+// job is a skiplist change applied by the channel job worker
+type job struct {
+	op       jobOp
+	gen      uint32 // jobs queued before Replace are dropped
+	member   string
+	score    int64
+	oldScore int64
+}
+
+// Backpressure is the policy of writes when the skiplist job queue is full
+type Backpressure int32
+
+const (
+	// BackpressureBlock waits until the queue has room or the context is done
+	BackpressureBlock Backpressure = iota
+	// BackpressureTimeout waits at most the timeout given to SetBackpressure
+	BackpressureTimeout
+	// BackpressureDrop rejects the write at once and counts it
+	BackpressureDrop
+	// BackpressureSync applies the queued jobs and the write in the caller
+	BackpressureSync
+)
+
+// ErrQueueFull is returned when a write is rejected because the skiplist job queue is full
+var ErrQueueFull = errors.New("sortedset: job queue is full")
+
+// BackpressureStats counts the writes which found the job queue full
+type BackpressureStats struct {
+	Dropped  int64 // rejected by BackpressureDrop
+	TimedOut int64 // rejected by BackpressureTimeout or by the context
+	Synced   int64 // applied in the caller by BackpressureSync
+}
+
+type dictShard struct {
+	lock sync.RWMutex
+	m    map[string]Element
+}
+
 // SortedSet is a set which keys sorted by bound score
 type SortedSet struct {
-	dict     sync.Map
+	dict     [shardCount]dictShard
 	skiplist *skiplist
 
 	elementCount int64
 	lock         sync.Mutex
-	slChannel    chan func()
+	slChannel    chan job
+	// gen is changed by Replace while holding all shard locks and the lock
+	gen uint32
+
+	onRemove func(member string)
+	onPanic  func(value interface{}, stack []byte)
+
+	// recvSem is held while a job is received and applied, so BackpressureSync callers can drain the queue in order
+	recvSem      chan struct{}
+	backpressure int32
+	timeout      int64
+	stats        BackpressureStats
 }
 
-// Make makes a new SortedSet
+// Make makes a new SortedSet, the skiplist worker runs in a plain goroutine
 func Make() *SortedSet {
+	return MakeWithRunner(func(name string, job func()) {
+		go job()
+	})
+}
 
+// MakeWithRunner makes a new SortedSet, the skiplist worker is started by run, e.g. supervisor.Supervisor.Go
+func MakeWithRunner(run func(name string, job func())) *SortedSet {
 	s := &SortedSet{
 		skiplist:     makeSkiplist(),
 		elementCount: 0,
-		slChannel:    make(chan func(), 20000),
+		slChannel:    make(chan job, 20000),
+		recvSem:      make(chan struct{}, 1),
+	}
+	for i := range s.dict {
+		s.dict[i].m = make(map[string]Element)
 	}
-	s.handleChannelJob()
+	run("sortedset", s.handleChannelJob)
 	return s
 }
 
 func (sortedSet *SortedSet) handleChannelJob() {
-	go func() {
-		for {
-			f := <-sortedSet.slChannel
-			sortedSet.lock.Lock()
-			f()
-			sortedSet.lock.Unlock()
+	for {
+		sortedSet.receiveJob()
+	}
+}
+
+func (sortedSet *SortedSet) receiveJob() {
+	sortedSet.recvSem <- struct{}{}
+	defer func() { <-sortedSet.recvSem }()
+	sortedSet.applyJob(<-sortedSet.slChannel)
+}
+
+// SetBackpressure sets the policy of writes when the job queue is full, timeout is used by BackpressureTimeout.
+// Remove is never rejected, it falls back to BackpressureSync unless the policy is BackpressureBlock
+func (sortedSet *SortedSet) SetBackpressure(policy Backpressure, timeout time.Duration) {
+	atomic.StoreInt64(&sortedSet.timeout, int64(timeout))
+	atomic.StoreInt32(&sortedSet.backpressure, int32(policy))
+}
+
+// GetBackpressureStats returns the counts of writes which found the job queue full
+func (sortedSet *SortedSet) GetBackpressureStats() BackpressureStats {
+	return BackpressureStats{
+		Dropped:  atomic.LoadInt64(&sortedSet.stats.Dropped),
+		TimedOut: atomic.LoadInt64(&sortedSet.stats.TimedOut),
+		Synced:   atomic.LoadInt64(&sortedSet.stats.Synced),
+	}
+}
+
+// enqueue sends j to the worker following the backpressure policy, the caller must hold the shard lock of the member
+func (sortedSet *SortedSet) enqueue(ctx context.Context, j job) error {
+	select {
+	case sortedSet.slChannel <- j:
+		return nil
+	default:
+	}
+	policy := Backpressure(atomic.LoadInt32(&sortedSet.backpressure))
+	if j.op == jobRemove && policy != BackpressureBlock {
+		policy = BackpressureSync
+	}
+	switch policy {
+	case BackpressureTimeout:
+		timer := time.NewTimer(time.Duration(atomic.LoadInt64(&sortedSet.timeout)))
+		defer timer.Stop()
+		select {
+		case sortedSet.slChannel <- j:
+			return nil
+		case <-timer.C:
+			atomic.AddInt64(&sortedSet.stats.TimedOut, 1)
+			return ErrQueueFull
+		case <-ctx.Done():
+			atomic.AddInt64(&sortedSet.stats.TimedOut, 1)
+			return ctx.Err()
+		}
+	case BackpressureDrop:
+		atomic.AddInt64(&sortedSet.stats.Dropped, 1)
+		return ErrQueueFull
+	case BackpressureSync:
+		sortedSet.applySync(j)
+		return nil
+	default:
+		select {
+		case sortedSet.slChannel <- j:
+			return nil
+		case <-ctx.Done():
+			atomic.AddInt64(&sortedSet.stats.TimedOut, 1)
+			return ctx.Err()
+		}
+	}
+}
+
+// applySync applies the queued jobs and j in the caller once it holds recvSem, jobs queued before j are applied first so the order is kept.
+// If the worker frees a slot first, j is just queued
+func (sortedSet *SortedSet) applySync(j job) {
+	select {
+	case sortedSet.slChannel <- j:
+	case sortedSet.recvSem <- struct{}{}:
+		for n := len(sortedSet.slChannel); n > 0; n-- {
+			sortedSet.applyJob(<-sortedSet.slChannel)
+		}
+		sortedSet.applyJob(j)
+		<-sortedSet.recvSem
+		atomic.AddInt64(&sortedSet.stats.Synced, 1)
+	}
+}
+
+// applyJob applies j to the skiplist, a panic drops the job and is passed to the OnPanic callback, so the lock is released and slChannel keeps draining
+func (sortedSet *SortedSet) applyJob(j job) {
+	defer func() {
+		if r := recover(); r != nil && sortedSet.onPanic != nil {
+			sortedSet.onPanic(r, debug.Stack())
 		}
 	}()
+	sortedSet.lock.Lock()
+	defer sortedSet.lock.Unlock()
+	if j.gen != sortedSet.gen {
+		return
+	}
+	switch j.op {
+	case jobInsert:
+		sortedSet.skiplist.insert(j.member, j.score)
+	case jobUpdate:
+		sortedSet.skiplist.remove(j.member, j.oldScore)
+		sortedSet.skiplist.insert(j.member, j.score)
+	case jobRemove:
+		sortedSet.skiplist.remove(j.member, j.score)
+	}
 }
 
-// Add puts member into set,  and returns whether has inserted new node
-func (sortedSet *SortedSet) Add(member string, score int64, value interface{}) {
-	element, exist := sortedSet.dict.Load(member)
-	sortedSet.dict.Store(member, &Element{
-		//Member: member,
-		Score: score,
-		Value: value,
-	})
+// shard returns the dict shard of member
+func (sortedSet *SortedSet) shard(member string) *dictShard {
+	return &sortedSet.dict[shardIndex(member)]
+}
+
+// shardIndex is fnv-1a hash of member
+func shardIndex(member string) uint32 {
+	hash := uint32(2166136261)
+	for i := 0; i < len(member); i++ {
+		hash ^= uint32(member[i])
+		hash *= 16777619
+	}
+	return hash % shardCount
+}
+
+// Add puts member into set,  and returns whether has inserted new node. A write rejected by the backpressure policy returns false
+func (sortedSet *SortedSet) Add(member string, element Element) bool {
+	added, _ := sortedSet.AddContext(context.Background(), member, element)
+	return added
+}
+
+// AddContext puts member into set like Add, returns ErrQueueFull or the context error if the write is rejected by the backpressure policy, the set is unchanged then
+func (sortedSet *SortedSet) AddContext(ctx context.Context, member string, element Element) (bool, error) {
+	shard := sortedSet.shard(member)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+	old, exist := shard.m[member]
+	// skiplist jobs of one member are queued in order under the shard lock
 	if !exist {
-		atomic.AddInt64(&sortedSet.elementCount, 1)
-		//log.Println("count after add", sortedSet.elementCount)
-		fc := func() {
-			sortedSet.skiplist.insert(member, score)
+		if err := sortedSet.enqueue(ctx, job{op: jobInsert, gen: sortedSet.gen, member: member, score: element.Score}); err != nil {
+			return false, err
 		}
-		sortedSet.slChannel <- fc
-	} else {
-		elementScore := element.(*Element).Score
-		if score != elementScore {
-			fc := func() {
-				sortedSet.skiplist.remove(member, elementScore)
-				sortedSet.skiplist.insert(member, score)
-			}
-			sortedSet.slChannel <- fc
+		atomic.AddInt64(&sortedSet.elementCount, 1)
+	} else if element.Score != old.Score {
+		if err := sortedSet.enqueue(ctx, job{op: jobUpdate, gen: sortedSet.gen, member: member, score: element.Score, oldScore: old.Score}); err != nil {
+			return false, err
 		}
-		//log.Println("count after cover",sortedSet.elementCount)
 	}
+	shard.m[member] = element
+	return !exist, nil
 }
 
-func (sortedSet *SortedSet) Remove(member string) {
-	element, exist := sortedSet.dict.Load(member)
+// Update calls f with a copy of the member's element, and stores it if f returns true. It is atomic with respect to Add and Remove of the member.
+// Returns false if member not exist, f returns false or the write is rejected by the backpressure policy
+func (sortedSet *SortedSet) Update(member string, f func(element *Element) bool) bool {
+	shard := sortedSet.shard(member)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+	old, exist := shard.m[member]
 	if !exist {
-		return
+		return false
 	}
-	atomic.AddInt64(&sortedSet.elementCount, -1)
-	elementScore := element.(*Element).Score
-	fc := func() {
-		sortedSet.skiplist.remove(member, elementScore)
+	element := old
+	if !f(&element) {
+		return false
+	}
+	if element.Score != old.Score {
+		if sortedSet.enqueue(context.Background(), job{op: jobUpdate, gen: sortedSet.gen, member: member, score: element.Score, oldScore: old.Score}) != nil {
+			return false
+		}
+	}
+	shard.m[member] = element
+	return true
+}
+
+// Remove removes member from set, returns false if member not exist
+func (sortedSet *SortedSet) Remove(member string) bool {
+	return sortedSet.RemoveIf(member, nil)
+}
+
+// RemoveIf removes member from set if f returns true for its element, f nil removes it unconditionally. It is atomic with respect to Add and Update of the member.
+// Returns false if member not exist or f returns false
+func (sortedSet *SortedSet) RemoveIf(member string, f func(element Element) bool) bool {
+	shard := sortedSet.shard(member)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+	element, exist := shard.m[member]
+	if !exist || (f != nil && !f(element)) {
+		return false
 	}
-	sortedSet.slChannel <- fc
-	sortedSet.dict.Delete(member)
+	sortedSet.enqueue(context.Background(), job{op: jobRemove, gen: sortedSet.gen, member: member, score: element.Score})
+	delete(shard.m, member)
+	atomic.AddInt64(&sortedSet.elementCount, -1)
+	return true
+}
+
+// OnRemove registers a callback which is called for every member removed by RemoveByScore or RemoveByRank
+func (sortedSet *SortedSet) OnRemove(f func(member string)) {
+	sortedSet.onRemove = f
+}
+
+// OnPanic registers a callback which is called with the recovered value and stack of a panicked skiplist job
+func (sortedSet *SortedSet) OnPanic(f func(value interface{}, stack []byte)) {
+	sortedSet.onPanic = f
 }
 
 // Len returns number of members in set
 func (sortedSet *SortedSet) Len() int64 {
-	return sortedSet.elementCount
+	return atomic.LoadInt64(&sortedSet.elementCount)
 }
 
 func (sortedSet *SortedSet) SLen() int64 {
+	sortedSet.lock.Lock()
+	defer sortedSet.lock.Unlock()
 	return sortedSet.skiplist.length
 }
 
 func (sortedSet *SortedSet) MapLen() int64 {
 	count := int64(0)
-	sortedSet.dict.Range(func(key, value interface{}) bool {
-		count++
-		return true
-	})
+	for i := range sortedSet.dict {
+		shard := &sortedSet.dict[i]
+		shard.lock.RLock()
+		count += int64(len(shard.m))
+		shard.lock.RUnlock()
+	}
 	return count
 }
 
-// Get returns the given member
-func (sortedSet *SortedSet) Get(member string) (element *Element, ok bool) {
-	elementI, exist := sortedSet.dict.Load(member)
-	if !exist {
-		return nil, false
+// Range calls f for each member in the dict, stops if f returns false. f is called without holding locks, so it can modify the set
+func (sortedSet *SortedSet) Range(f func(member string, element Element) bool) {
+	members := make([]string, 0)
+	elements := make([]Element, 0)
+	for i := range sortedSet.dict {
+		shard := &sortedSet.dict[i]
+		members = members[:0]
+		elements = elements[:0]
+		shard.lock.RLock()
+		for member, element := range shard.m {
+			members = append(members, member)
+			elements = append(elements, element)
+		}
+		shard.lock.RUnlock()
+		for j := range members {
+			if !f(members[j], elements[j]) {
+				return
+			}
+		}
 	}
-	return elementI.(*Element), true
 }
 
-// ForEachByScore visits members which score within the given border
+// Get returns the given member
+func (sortedSet *SortedSet) Get(member string) (element Element, ok bool) {
+	shard := sortedSet.shard(member)
+	shard.lock.RLock()
+	element, ok = shard.m[member]
+	shard.lock.RUnlock()
+	return element, ok
+}
+
+// ForEachByScore visits members which score within the given border, the caller must hold the lock
 func (sortedSet *SortedSet) ForEachByScore(min int64, max int64, offset int64, limit int64, desc bool, consumer func(node *node) bool) {
 	// find start node
 	var node *node
@@ -149,18 +401,25 @@ func (sortedSet *SortedSet) ForEachByScore(min int64, max int64, offset int64, l
 
 // RangeByScore returns members which score within the given border
 // param limit: <0 means no limit
-func (sortedSet *SortedSet) RangeByScore(min int64, max int64, offset int64, limit int64, desc bool) []*Element {
+func (sortedSet *SortedSet) RangeByScore(min int64, max int64, offset int64, limit int64, desc bool) []Element {
 	if limit == 0 || offset < 0 {
-		return make([]*Element, 0)
+		return make([]Element, 0)
 	}
-	slice := make([]*Element, 0)
+	members := make([]string, 0)
+	sortedSet.lock.Lock()
 	sortedSet.ForEachByScore(min, max, offset, limit, desc, func(node *node) bool {
-		element, ok := sortedSet.dict.Load(node.Member)
-		if ok {
-			slice = append(slice, element.(*Element))
-		}
+		members = append(members, node.Member)
 		return true
 	})
+	sortedSet.lock.Unlock()
+
+	slice := make([]Element, 0, len(members))
+	for _, member := range members {
+		element, ok := sortedSet.Get(member)
+		if ok {
+			slice = append(slice, element)
+		}
+	}
 	return slice
 }
 
@@ -168,14 +427,21 @@ func (sortedSet *SortedSet) RangeByScore(min int64, max int64, offset int64, lim
 func (sortedSet *SortedSet) RemoveByScore(max int64) int64 {
 
 	sortedSet.lock.Lock()
-	removed := sortedSet.skiplist.RemoveRangeByScore(0, max)
+	removed := sortedSet.skiplist.RemoveRangeByScore(0, max, -1)
+	gen := sortedSet.gen
 	sortedSet.lock.Unlock()
-	for _, element := range removed {
-		sortedSet.dict.Delete(element)
-	}
-	sortedSet.elementCount = sortedSet.SLen()
-	//log.Println("count after RemoveByScore", "map len", sortedSet.elementCount, "list len", sortedSet.SLen())
-	return int64(len(removed))
+	return sortedSet.removeFromDict(removed, gen)
+}
+
+// RemoveByScoreLimit removes at most limit members which score <= max, so the lock is held for a bounded time.
+// Returns the count removed from the skiplist, less than limit means no more members in range
+func (sortedSet *SortedSet) RemoveByScoreLimit(max int64, limit int) int {
+	sortedSet.lock.Lock()
+	removed := sortedSet.skiplist.RemoveRangeByScore(0, max, limit)
+	gen := sortedSet.gen
+	sortedSet.lock.Unlock()
+	sortedSet.removeFromDict(removed, gen)
+	return len(removed)
 }
 
 // RemoveByRank removes member ranking within [start, stop)
@@ -184,11 +450,96 @@ func (sortedSet *SortedSet) RemoveByRank(start int64, stop int64) int64 {
 
 	sortedSet.lock.Lock()
 	removed := sortedSet.skiplist.RemoveRangeByRank(start+1, stop+1)
+	gen := sortedSet.gen
+	sortedSet.lock.Unlock()
+	return sortedSet.removeFromDict(removed, gen)
+}
+
+// removeFromDict deletes members removed from the skiplist of gen, unless they have been set again with another score
+func (sortedSet *SortedSet) removeFromDict(removed []memberScore, gen uint32) int64 {
+	count := int64(0)
+	for _, r := range removed {
+		shard := sortedSet.shard(r.member)
+		shard.lock.Lock()
+		element, exist := shard.m[r.member]
+		if exist && element.Score == r.score && gen == sortedSet.gen {
+			delete(shard.m, r.member)
+			atomic.AddInt64(&sortedSet.elementCount, -1)
+			count++
+		} else {
+			exist = false
+		}
+		shard.lock.Unlock()
+		if exist && sortedSet.onRemove != nil {
+			sortedSet.onRemove(r.member)
+		}
+	}
+	return count
+}
+
+// Snapshot is a SortedSet built offline, and swapped in by Replace. It is not safe for concurrent use
+type Snapshot struct {
+	dict     [shardCount]map[string]Element
+	skiplist *skiplist
+	count    int64
+}
+
+// MakeSnapshot makes a new empty Snapshot
+func MakeSnapshot() *Snapshot {
+	sn := &Snapshot{
+		skiplist: makeSkiplist(),
+	}
+	for i := range sn.dict {
+		sn.dict[i] = make(map[string]Element)
+	}
+	return sn
+}
+
+// Add puts member into the snapshot
+func (sn *Snapshot) Add(member string, element Element) {
+	m := sn.dict[shardIndex(member)]
+	old, exist := m[member]
+	if exist {
+		sn.skiplist.remove(member, old.Score)
+	} else {
+		sn.count++
+	}
+	m[member] = element
+	sn.skiplist.insert(member, element.Score)
+}
+
+// Len returns number of members in the snapshot
+func (sn *Snapshot) Len() int64 {
+	return sn.count
+}
+
+// Range calls f for each member in the snapshot, stops if f returns false
+func (sn *Snapshot) Range(f func(member string, element Element) bool) {
+	for _, m := range sn.dict {
+		for member, element := range m {
+			if !f(member, element) {
+				return
+			}
+		}
+	}
+}
+
+// Replace atomically swaps the content of the set with the snapshot, the snapshot must not be used afterwards.
+// Skiplist jobs queued before are dropped, and OnRemove is not called for the replaced members. Returns number of members replaced
+func (sortedSet *SortedSet) Replace(sn *Snapshot) int64 {
+	for i := range sortedSet.dict {
+		sortedSet.dict[i].lock.Lock()
+	}
+	sortedSet.lock.Lock()
+	for i := range sortedSet.dict {
+		sortedSet.dict[i].m = sn.dict[i]
+	}
+	sortedSet.skiplist = sn.skiplist
+	sortedSet.gen++
+	old := atomic.SwapInt64(&sortedSet.elementCount, sn.count)
 	sortedSet.lock.Unlock()
-	for _, element := range removed {
-		sortedSet.dict.Delete(element)
+	for i := range sortedSet.dict {
+		sortedSet.dict[i].lock.Unlock()
 	}
-	sortedSet.elementCount = sortedSet.SLen()
-	//log.Println("count after RemoveByRank", "map len", sortedSet.elementCount, "list len", sortedSet.SLen())
-	return int64(len(removed))
+	return old
 }
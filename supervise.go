@@ -0,0 +1,27 @@
+package go_fast_cache
+
+import (
+	"github.com/daqnext/go-fast-cache/supervisor"
+	"time"
+)
+
+// SetOnError registers f which is called with the job name and the error when a background job panics, f must not block.
+// Janitors are restarted after the backoff, a panicked skiplist job is dropped and the skiplist worker goes on
+func (lc *LocalCache) SetOnError(f func(job string, err error)) {
+	lc.sup.SetOnError(f)
+}
+
+// SetRestartBackoff sets the min and max delay before restarting a panicked janitor, default is supervisor.DefaultMinBackoff and supervisor.DefaultMaxBackoff
+func (lc *LocalCache) SetRestartBackoff(min time.Duration, max time.Duration) {
+	lc.sup.SetBackoff(min, max)
+}
+
+// Health reports whether the background jobs (skiplist worker and janitors) are alive, with their restart and error counts
+func (lc *LocalCache) Health() supervisor.Health {
+	return lc.sup.Health()
+}
+
+// onSortedSetPanic is the sortedset OnPanic callback
+func (lc *LocalCache) onSortedSetPanic(value interface{}, stack []byte) {
+	lc.sup.Report("sortedset", &supervisor.PanicError{Value: value, Stack: stack})
+}
@@ -0,0 +1,197 @@
+package supervisor
+
+import (
+	"fmt"
+	"github.com/daqnext/go-fast-cache/logging"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMinBackoff is the delay before the first restart of a panicked job
+	DefaultMinBackoff = 100 * time.Millisecond
+	// DefaultMaxBackoff is the max delay between restarts, the delay doubles on every panic and is reset if the job ran longer than it
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// PanicError is the error reported for a panicked job
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// WorkerHealth is the state of one supervised job
+type WorkerHealth struct {
+	Name        string
+	Alive       bool  // false after the job returned or while waiting for a restart
+	Restarts    int64 // count of restarts after panics
+	Errors      int64 // count of errors reported, including the ones recovered inside the job
+	LastError   error
+	LastErrorAt time.Time
+}
+
+// Health is the state of all supervised jobs
+type Health struct {
+	Alive   bool // all workers are alive
+	Workers []WorkerHealth
+}
+
+// Supervisor runs long running jobs, restarts them with backoff on panic and reports the errors
+type Supervisor struct {
+	llog logging.Logger
+
+	lock       sync.Mutex
+	onError    func(job string, err error)
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	workers    []*WorkerHealth
+}
+
+// New Instance of Supervisor, if logger is nil nothing is logged
+func New(logger logging.Logger) *Supervisor {
+	return &Supervisor{
+		llog:       logging.OrNop(logger),
+		minBackoff: DefaultMinBackoff,
+		maxBackoff: DefaultMaxBackoff,
+	}
+}
+
+// SetOnError registers f which is called with the name of the job and the error for every panic, f must not block
+func (s *Supervisor) SetOnError(f func(job string, err error)) {
+	s.lock.Lock()
+	s.onError = f
+	s.lock.Unlock()
+}
+
+// SetBackoff sets the min and max delay between restarts of a panicked job, see DefaultMinBackoff and DefaultMaxBackoff
+func (s *Supervisor) SetBackoff(min time.Duration, max time.Duration) {
+	if min <= 0 {
+		min = DefaultMinBackoff
+	}
+	if max < min {
+		max = min
+	}
+	s.lock.Lock()
+	s.minBackoff = min
+	s.maxBackoff = max
+	s.lock.Unlock()
+}
+
+// Go runs job in a new goroutine named name, if job panics the error is reported and job is started again after the backoff
+func (s *Supervisor) Go(name string, job func()) {
+	s.lock.Lock()
+	w := &WorkerHealth{Name: name, Alive: true}
+	s.workers = append(s.workers, w)
+	s.lock.Unlock()
+
+	go func() {
+		backoff := time.Duration(0)
+		for {
+			start := time.Now()
+			err := run(job)
+			if err == nil {
+				s.lock.Lock()
+				w.Alive = false
+				s.lock.Unlock()
+				return
+			}
+			s.report(w, err, false)
+
+			s.lock.Lock()
+			min, max := s.minBackoff, s.maxBackoff
+			s.lock.Unlock()
+			if backoff == 0 || time.Since(start) > max {
+				backoff = min
+			} else {
+				backoff *= 2
+				if backoff > max {
+					backoff = max
+				}
+			}
+			time.Sleep(backoff)
+
+			s.lock.Lock()
+			w.Alive = true
+			w.Restarts++
+			s.lock.Unlock()
+		}
+	}()
+}
+
+// Report reports an error recovered inside the job named name, the job keeps alive
+func (s *Supervisor) Report(name string, err error) {
+	s.lock.Lock()
+	var w *WorkerHealth
+	for _, worker := range s.workers {
+		if worker.Name == name {
+			w = worker
+			break
+		}
+	}
+	s.lock.Unlock()
+	s.report(w, err, true)
+}
+
+// report records err on w if not nil, logs it and calls the OnError hook
+func (s *Supervisor) report(w *WorkerHealth, err error, alive bool) {
+	s.lock.Lock()
+	name := ""
+	if w != nil {
+		name = w.Name
+		w.Alive = alive
+		w.Errors++
+		w.LastError = err
+		w.LastErrorAt = time.Now()
+	}
+	onError := s.onError
+	s.lock.Unlock()
+
+	if pe, ok := err.(*PanicError); ok {
+		s.llog.Errorf("%s %v\n%s", name, err, pe.Stack)
+	} else {
+		s.llog.Errorf("%s %v", name, err)
+	}
+	if onError != nil {
+		s.callOnError(onError, name, err)
+	}
+}
+
+// callOnError keeps a panicking hook from killing the supervisor goroutine
+func (s *Supervisor) callOnError(onError func(job string, err error), name string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.llog.Errorf("%s OnError hook panic: %v", name, r)
+		}
+	}()
+	onError(name, err)
+}
+
+// Health returns the state of all jobs started by Go
+func (s *Supervisor) Health() Health {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	h := Health{Alive: true, Workers: make([]WorkerHealth, 0, len(s.workers))}
+	for _, w := range s.workers {
+		h.Workers = append(h.Workers, *w)
+		if !w.Alive {
+			h.Alive = false
+		}
+	}
+	return h
+}
+
+// run returns the PanicError if job panicked
+func run(job func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	job()
+	return nil
+}
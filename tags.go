@@ -0,0 +1,92 @@
+package go_fast_cache
+
+import (
+	"context"
+	"github.com/daqnext/go-fast-cache/sortedset"
+	"sync/atomic"
+)
+
+// SetWithTags Set key value with expire time like Set, and attach tags to it. All keys carrying a tag can be removed together by InvalidateTag
+func (lc *LocalCache) SetWithTags(key string, value interface{}, ttlSecond int64, tags ...string) {
+	lc.SetContext(withOp(context.Background(), &coreOp{tags: tags}), key, value, ttlSecond)
+}
+
+// addTagged adds the element of key and attaches tags to it
+func (lc *LocalCache) addTagged(ctx context.Context, key string, e sortedset.Element, tags []string) error {
+	lc.tagLock.Lock()
+	defer lc.tagLock.Unlock()
+	added, err := lc.s.AddContext(ctx, key, e)
+	if err != nil {
+		return err
+	}
+	lc.untag(key)
+	if added {
+		lc.added(key)
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	keyTags := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		keys, exist := lc.tagKeys[tag]
+		if !exist {
+			keys = make(map[string]struct{})
+			lc.tagKeys[tag] = keys
+		}
+		if _, dup := keys[key]; dup {
+			continue
+		}
+		keys[key] = struct{}{}
+		keyTags = append(keyTags, tag)
+	}
+	lc.keyTags[key] = keyTags
+	atomic.AddInt64(&lc.tagged, 1)
+	return nil
+}
+
+// InvalidateTag removes every key carrying the tag, returns the count of removed keys
+func (lc *LocalCache) InvalidateTag(tag string) int64 {
+	lc.tagLock.Lock()
+	defer lc.tagLock.Unlock()
+	keys, exist := lc.tagKeys[tag]
+	if !exist {
+		return 0
+	}
+	count := int64(0)
+	for key := range keys {
+		lc.untag(key)
+		if lc.s.Remove(key) {
+			lc.deleted(key)
+			count++
+		}
+	}
+	return count
+}
+
+// GetTags returns the tags attached to key
+func (lc *LocalCache) GetTags(key string) []string {
+	lc.tagLock.RLock()
+	defer lc.tagLock.RUnlock()
+	tags := lc.keyTags[key]
+	result := make([]string, len(tags))
+	copy(result, tags)
+	return result
+}
+
+// untag removes the tag bookkeeping of key, tagLock must be held
+func (lc *LocalCache) untag(key string) {
+	tags, exist := lc.keyTags[key]
+	if !exist {
+		return
+	}
+	for _, tag := range tags {
+		keys := lc.tagKeys[tag]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(lc.tagKeys, tag)
+		}
+	}
+	delete(lc.keyTags, key)
+	atomic.AddInt64(&lc.tagged, -1)
+}
@@ -0,0 +1,114 @@
+package test
+
+import (
+	"context"
+	localcache "github.com/daqnext/go-fast-cache"
+	"github.com/daqnext/go-fast-cache/sortedset"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// stalledSet returns a SortedSet with a full job queue, its worker starts when start is closed
+func stalledSet(t *testing.T) (*sortedset.SortedSet, chan struct{}) {
+	start := make(chan struct{})
+	s := sortedset.MakeWithRunner(func(name string, job func()) {
+		go func() {
+			<-start
+			job()
+		}()
+	})
+	for i := 0; i < 20000; i++ {
+		s.Add(strconv.Itoa(i), sortedset.Element{Score: int64(i + 1)})
+	}
+	return s, start
+}
+
+func Test_BackpressureDrop(t *testing.T) {
+	s, start := stalledSet(t)
+	defer close(start)
+	s.SetBackpressure(sortedset.BackpressureDrop, 0)
+	if s.Add("x", sortedset.Element{Score: 1}) {
+		t.Fatal("add into a full queue")
+	}
+	if _, exist := s.Get("x"); exist {
+		t.Fatal("dropped member stored")
+	}
+	if _, err := s.AddContext(context.Background(), "y", sortedset.Element{Score: 1}); err != sortedset.ErrQueueFull {
+		t.Fatalf("err %v", err)
+	}
+	if stats := s.GetBackpressureStats(); stats.Dropped != 2 {
+		t.Fatalf("stats %+v", stats)
+	}
+	//remove is never dropped
+	if !s.Remove("0") {
+		t.Fatal("remove")
+	}
+	if stats := s.GetBackpressureStats(); stats.Synced != 1 {
+		t.Fatalf("stats %+v", stats)
+	}
+}
+
+func Test_BackpressureTimeout(t *testing.T) {
+	s, start := stalledSet(t)
+	defer close(start)
+	s.SetBackpressure(sortedset.BackpressureTimeout, 50*time.Millisecond)
+	begin := time.Now()
+	if _, err := s.AddContext(context.Background(), "x", sortedset.Element{Score: 1}); err != sortedset.ErrQueueFull {
+		t.Fatalf("err %v", err)
+	}
+	if time.Since(begin) < 50*time.Millisecond {
+		t.Fatal("returned before timeout")
+	}
+
+	//block policy waits for the context deadline
+	s.SetBackpressure(sortedset.BackpressureBlock, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.AddContext(ctx, "x", sortedset.Element{Score: 1}); err != context.DeadlineExceeded {
+		t.Fatalf("err %v", err)
+	}
+	if stats := s.GetBackpressureStats(); stats.TimedOut != 2 {
+		t.Fatalf("stats %+v", stats)
+	}
+}
+
+func Test_BackpressureSync(t *testing.T) {
+	s, start := stalledSet(t)
+	s.SetBackpressure(sortedset.BackpressureSync, 0)
+	//the sync write drains the queue first, so the update of 5 is applied after its insert
+	s.Add("5", sortedset.Element{Score: 30000})
+	s.Add("x", sortedset.Element{Score: 20001})
+	if stats := s.GetBackpressureStats(); stats.Synced != 1 {
+		t.Fatalf("stats %+v", stats)
+	}
+	close(start)
+	time.Sleep(100 * time.Millisecond)
+	if s.SLen() != 20001 || s.Len() != 20001 {
+		t.Fatalf("skiplist len %d, len %d", s.SLen(), s.Len())
+	}
+	if removed := s.RemoveByScore(20000); removed != 19999 {
+		t.Fatalf("removed %d", removed)
+	}
+	if _, exist := s.Get("5"); !exist {
+		t.Fatal("updated member removed")
+	}
+}
+
+func Test_SetContext(t *testing.T) {
+	lc := localcache.New(log)
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := lc.SetContext(ctx, "a", 1, 10); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := lc.SetContext(ctx, "b", 1, 10); err != context.Canceled {
+		t.Fatalf("err %v", err)
+	}
+	if _, _, exist := lc.Get("b"); exist {
+		t.Fatal("set with canceled context")
+	}
+	if stats := lc.GetBackpressureStats(); stats != (localcache.BackpressureStats{}) {
+		t.Fatalf("stats %+v", stats)
+	}
+}
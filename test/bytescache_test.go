@@ -0,0 +1,68 @@
+package test
+
+import (
+	"bytes"
+	"github.com/daqnext/go-fast-cache/bytescache"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_BytesCache(t *testing.T) {
+	c := bytescache.New(1, log)
+	c.Set("foo", []byte("bar"), 2)
+	//the clock may tick between Set and Get
+	v, ttl, exist := c.Get("foo")
+	if !exist || string(v) != "bar" || ttl < 1 || ttl > 2 {
+		t.Fatalf("get = %s %d %v", v, ttl, exist)
+	}
+	c.Set("foo", []byte("baz"), 100)
+	if v, _, _ := c.Get("foo"); string(v) != "baz" {
+		t.Fatalf("overwrite get = %s", v)
+	}
+	c.Delete("foo")
+	if _, _, exist := c.Get("foo"); exist {
+		t.Fatal("foo should be deleted")
+	}
+	if err := c.Set("big", make([]byte, 1024*1024), 100); err != bytescache.ErrEntryTooLarge {
+		t.Fatalf("set big err = %v", err)
+	}
+
+	c.Set("short", []byte("1"), 1)
+	time.Sleep(2 * time.Second)
+	if _, _, exist := c.Get("short"); exist {
+		t.Fatal("short should be expired")
+	}
+}
+
+func Test_BytesCacheOverwriteOldest(t *testing.T) {
+	c := bytescache.New(1, log)
+	value := bytes.Repeat([]byte("a"), 100)
+	//about 2MB written into 1MB of arenas
+	for i := 0; i < 20000; i++ {
+		c.Set(strconv.Itoa(i), value, 300)
+	}
+	if _, _, exist := c.Get("0"); exist {
+		t.Fatal("oldest key should be overwritten")
+	}
+	v, _, exist := c.Get("19999")
+	if !exist || !bytes.Equal(v, value) {
+		t.Fatal("newest key should exist")
+	}
+	if n := c.GetLen(); n <= 0 || n >= 20000 {
+		t.Fatalf("len %d", n)
+	}
+}
+
+func BenchmarkBytesCache_Set(b *testing.B) {
+	c := bytescache.New(256, log)
+	value := bytes.Repeat([]byte("a"), 100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Set(strconv.Itoa(i), value, 300)
+	}
+	b.StopTimer()
+	runtime.GC()
+}
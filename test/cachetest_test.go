@@ -0,0 +1,66 @@
+package test
+
+import (
+	localcache "github.com/daqnext/go-fast-cache"
+	"github.com/daqnext/go-fast-cache/cachetest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ConformanceLocalCache(t *testing.T) {
+	cachetest.Run(t, func(t *testing.T) (localcache.Cache, func(time.Duration)) {
+		return localcache.New(log), time.Sleep
+	})
+}
+
+func Test_ConformanceMiddleware(t *testing.T) {
+	cachetest.Run(t, func(t *testing.T) (localcache.Cache, func(time.Duration)) {
+		lc := localcache.New(log)
+		lc.Use(func(next localcache.Handler) localcache.Handler {
+			return lowerKeys{next}
+		}, func(next localcache.Handler) localcache.Handler {
+			return sealValues{next}
+		})
+		return lc, time.Sleep
+	})
+}
+
+func Test_ConformanceFake(t *testing.T) {
+	cachetest.Run(t, func(t *testing.T) (localcache.Cache, func(time.Duration)) {
+		f := cachetest.NewFake()
+		return f, f.Advance
+	})
+}
+
+func Test_FakeDeterministic(t *testing.T) {
+	a, b := cachetest.NewFake(), cachetest.NewFake()
+	for i := 0; i < 3; i++ {
+		if ta, tb := a.SetRand("k", 10), b.SetRand("k", 10); ta != tb || len(ta) != 20 || strings.Trim(ta, "abcdefghijklmnopqrstuvwxyz") != "" {
+			t.Fatalf("tokens %q %q", ta, tb)
+		}
+	}
+	a.Set("x", 1, 5)
+	a.Advance(4 * time.Second)
+	if _, ttl, exist := a.Get("x"); !exist || ttl != 1 {
+		t.Fatalf("ttl %d %v", ttl, exist)
+	}
+	a.Advance(time.Second)
+	if _, _, exist := a.Get("x"); exist {
+		t.Fatal("expired")
+	}
+	if a.Now().Unix() != 5 {
+		t.Fatalf("now %v", a.Now())
+	}
+
+	//advances shorter than a second add up
+	a.Set("y", 1, 1)
+	a.Advance(600 * time.Millisecond)
+	if _, _, exist := a.Get("y"); !exist {
+		t.Fatal("y expired early")
+	}
+	a.Advance(600 * time.Millisecond)
+	if _, _, exist := a.Get("y"); exist {
+		t.Fatal("y should expire after 1.2s")
+	}
+}
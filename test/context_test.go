@@ -0,0 +1,121 @@
+package test
+
+import (
+	"context"
+	"errors"
+	localcache "github.com/daqnext/go-fast-cache"
+	"sync"
+	"testing"
+	"time"
+)
+
+type traceKey struct{}
+
+// recordHook keeps the trace id of the ctx of every call
+type recordHook struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (h *recordHook) record(ctx context.Context, event string) {
+	trace, _ := ctx.Value(traceKey{}).(string)
+	h.lock.Lock()
+	h.events = append(h.events, trace+" "+event)
+	h.lock.Unlock()
+}
+
+func (h *recordHook) OnGet(ctx context.Context, key string, hit bool) {
+	if hit {
+		h.record(ctx, "get "+key+" hit")
+	} else {
+		h.record(ctx, "get "+key+" miss")
+	}
+}
+
+func (h *recordHook) OnSet(ctx context.Context, key string, err error) {
+	h.record(ctx, "set "+key)
+}
+
+func (h *recordHook) OnLoad(ctx context.Context, key string, duration time.Duration, err error) {
+	if err != nil {
+		h.record(ctx, "load "+key+" "+err.Error())
+	} else {
+		h.record(ctx, "load "+key)
+	}
+}
+
+func Test_ContextHooks(t *testing.T) {
+	lc := localcache.New(log)
+	hook := &recordHook{}
+	lc.AddHook(hook)
+	ctx := context.WithValue(context.Background(), traceKey{}, "t1")
+
+	lc.SetCtx(ctx, "a", 1, 10)
+	lc.GetCtx(ctx, "a")
+	lc.GetCtx(ctx, "b")
+	lc.Get("a")
+	value, err := lc.GetOrLoadCtx(ctx, "c", 10, func(ctx context.Context) (interface{}, error) {
+		return ctx.Value(traceKey{}), nil
+	})
+	if err != nil || value != "t1" {
+		t.Fatalf("loaded %v %v", value, err)
+	}
+	lc.GetOrLoadCtx(ctx, "c", 10, nil)
+
+	want := []string{"t1 set a", "t1 get a hit", "t1 get b miss", " get a hit", "t1 get c miss", "t1 load c", "t1 get c hit"}
+	hook.lock.Lock()
+	defer hook.lock.Unlock()
+	if len(hook.events) != len(want) {
+		t.Fatalf("events %q", hook.events)
+	}
+	for i := range want {
+		if hook.events[i] != want[i] {
+			t.Fatalf("events %q", hook.events)
+		}
+	}
+}
+
+func Test_GetOrLoadCtxCancel(t *testing.T) {
+	lc := localcache.New(log)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := lc.GetOrLoadCtx(ctx, "a", 10, nil); err != context.Canceled {
+		t.Fatalf("err %v", err)
+	}
+
+	//a waiter gives up when its ctx is done, the shared loader goes on
+	release := make(chan struct{})
+	loaded := make(chan struct{})
+	go func() {
+		lc.GetOrLoadCtx(context.Background(), "b", 10, func(ctx context.Context) (interface{}, error) {
+			<-release
+			return "b", nil
+		})
+		close(loaded)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer waitCancel()
+	if _, err := lc.GetOrLoadCtx(waitCtx, "b", 10, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err %v", err)
+	}
+	close(release)
+	<-loaded
+	if value, _, _ := lc.Get("b"); value != "b" {
+		t.Fatalf("value %v", value)
+	}
+
+	//the loader gets the ctx of the call
+	loadCtx, loadCancel := context.WithCancel(context.Background())
+	_, err := lc.GetOrLoadCtx(loadCtx, "c", 10, func(ctx context.Context) (interface{}, error) {
+		loadCancel()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if err != context.Canceled {
+		t.Fatalf("err %v", err)
+	}
+	if _, _, exist := lc.Get("c"); exist {
+		t.Fatal("failed load cached")
+	}
+}
@@ -0,0 +1,93 @@
+package test
+
+import (
+	localcache "github.com/daqnext/go-fast-cache"
+	"github.com/daqnext/go-fast-cache/ttltype"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func Test_Hash(t *testing.T) {
+	lc := localcache.New(log)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			lc.HSet("user:1", strconv.Itoa(i), i, 300)
+		}(i)
+	}
+	wg.Wait()
+
+	all, _ := lc.HGetAll("user:1")
+	if len(all) != 100 {
+		t.Fatalf("HGetAll len %d, want 100", len(all))
+	}
+	if n, _ := lc.HDel("user:1", "1", "2", "1000"); n != 2 {
+		t.Fatalf("HDel removed %d, want 2", n)
+	}
+	if v, exist, _ := lc.HGet("user:1", "3"); !exist || v.(int) != 3 {
+		t.Fatalf("HGet = %v %v", v, exist)
+	}
+
+	lc.Set("str", "foo", 300)
+	if err := lc.HSet("str", "a", 1, ttltype.Keep); err != localcache.ErrWrongType {
+		t.Fatalf("HSet on string err = %v", err)
+	}
+
+	//a failed write leaves the ttl of the other kind of value unchanged
+	lc.Set("short", "foo", 10)
+	if err := lc.HSet("short", "a", 1, 3000); err != localcache.ErrWrongType {
+		t.Fatalf("HSet on string err = %v", err)
+	}
+	if _, err := lc.LPush("short", 3000, "a"); err != localcache.ErrWrongType {
+		t.Fatalf("LPush on string err = %v", err)
+	}
+	if v, ttl, _ := lc.Get("short"); v.(string) != "foo" || ttl > 10 {
+		t.Fatalf("string after failed writes = %v ttl %d", v, ttl)
+	}
+
+	if err := lc.HSet("neg", "a", 1, -1); err != localcache.ErrInvalidTTL {
+		t.Fatalf("HSet with negative ttl err = %v", err)
+	}
+	if _, exist, _ := lc.HGet("neg", "a"); exist {
+		t.Fatal("HSet with negative ttl should not store")
+	}
+}
+
+func Test_List(t *testing.T) {
+	lc := localcache.New(log)
+	lc.LPush("queue", 300, "a", "b")
+	lc.LPush("queue", ttltype.Keep, "c")
+
+	items, _ := lc.LRange("queue", 0, -1)
+	want := []string{"c", "b", "a"}
+	for i := range want {
+		if items[i].(string) != want[i] {
+			t.Fatalf("LRange = %v, want %v", items, want)
+		}
+	}
+	if v, _, _ := lc.RPop("queue"); v.(string) != "a" {
+		t.Fatalf("RPop = %v, want a", v)
+	}
+	if items, _ := lc.LRange("queue", -1, -1); len(items) != 1 || items[0].(string) != "b" {
+		t.Fatalf("LRange tail = %v", items)
+	}
+}
+
+func Test_Set_Members(t *testing.T) {
+	lc := localcache.New(log)
+	if n, _ := lc.SAdd("tags", 300, "go", "cache", "go"); n != 2 {
+		t.Fatalf("SAdd added %d, want 2", n)
+	}
+	if ok, _ := lc.SIsMember("tags", "go"); !ok {
+		t.Fatal("go should be member")
+	}
+	members, _ := lc.SMembers("tags")
+	sort.Strings(members)
+	if len(members) != 2 || members[0] != "cache" {
+		t.Fatalf("SMembers = %v", members)
+	}
+}
@@ -0,0 +1,29 @@
+package test
+
+import (
+	localcache "github.com/daqnext/go-fast-cache"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_EvictionWatermark(t *testing.T) {
+	lc := localcache.New(log)
+	lc.SetCountLimit(10000)
+	lc.SetEvictionWatermark(0.9, 0.5)
+
+	for i := 0; i < 9500; i++ {
+		lc.Set(strconv.Itoa(i), i, int64(i%3000+100))
+	}
+	//crossing the high watermark starts a pass without waiting for the check interval
+	time.Sleep(300 * time.Millisecond)
+
+	if n := lc.GetLen(); n > 9000 || n < 5000 {
+		t.Fatalf("len %d after eviction", n)
+	}
+	stats := lc.GetEvictionStats()
+	if stats.Passes == 0 || stats.Evicted == 0 {
+		t.Fatalf("stats %+v", stats)
+	}
+	log.Printf("eviction stats %+v", stats)
+}
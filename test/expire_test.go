@@ -0,0 +1,29 @@
+package test
+
+import (
+	localcache "github.com/daqnext/go-fast-cache"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_ExpireInBatches(t *testing.T) {
+	lc := localcache.NewWithInterval(1, log)
+	for i := 0; i < 300000; i++ {
+		lc.Set(strconv.Itoa(i), i, 1)
+	}
+	lc.Set("live", 1, 300)
+
+	for i := 0; i < 100; i++ {
+		time.Sleep(100 * time.Millisecond)
+		if lc.GetLen() == 1 {
+			break
+		}
+	}
+	if lc.GetLen() != 1 {
+		t.Fatalf("len %d, want 1", lc.GetLen())
+	}
+	if _, _, exist := lc.Get("live"); !exist {
+		t.Fatal("unexpired key should exist")
+	}
+}
@@ -0,0 +1,86 @@
+package test
+
+import (
+	localcache "github.com/daqnext/go-fast-cache"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_Flush(t *testing.T) {
+	lc := localcache.NewWithInterval(1, log)
+	for i := 0; i < 100000; i++ {
+		lc.Set(strconv.Itoa(i), i, 300)
+	}
+	lc.SetWithTags("tagged", 1, 300, "t")
+	ns := lc.Namespace("ns")
+	ns.Set("a", 1, 300)
+
+	//flush while other goroutines keep writing
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				lc.Set("w"+strconv.Itoa(i%100), i, 1)
+			}
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if n := lc.Flush(); n < 100002 {
+		t.Fatalf("flushed %d keys", n)
+	}
+	close(stop)
+	wg.Wait()
+
+	if _, _, exist := lc.Get("5"); exist {
+		t.Fatal("flushed key should not exist")
+	}
+	if lc.GetLen() > 100 || ns.Len() != 0 || len(lc.GetTags("tagged")) != 0 {
+		t.Fatalf("len %d ns len %d after flush", lc.GetLen(), ns.Len())
+	}
+
+	//keys written during and after flush are still expired by the background job
+	time.Sleep(3 * time.Second)
+	if lc.GetLen() != 0 {
+		t.Fatalf("len %d, keys set around flush should expire", lc.GetLen())
+	}
+}
+
+func Test_Replace(t *testing.T) {
+	lc := localcache.NewWithInterval(1, log)
+	lc.Set("old", 1, 300)
+	ns := lc.Namespace("ns")
+
+	snapshot := localcache.NewSnapshot()
+	for i := 0; i < 1000; i++ {
+		snapshot.Set(strconv.Itoa(i), i, 2)
+	}
+	snapshot.Set("ns:a", 1, 300)
+	snapshot.Set("keep", 1, 300)
+
+	if n := lc.Replace(snapshot); n != 1 {
+		t.Fatalf("replaced %d keys, want 1", n)
+	}
+	if _, _, exist := lc.Get("old"); exist {
+		t.Fatal("old key should be replaced")
+	}
+	//the clock may tick after the snapshot is built
+	if v, ttl, _ := lc.Get("999"); v.(int) != 999 || ttl < 1 || ttl > 2 {
+		t.Fatalf("get = %v %d", v, ttl)
+	}
+	if lc.GetLen() != 1002 || ns.Len() != 1 {
+		t.Fatalf("len %d ns len %d", lc.GetLen(), ns.Len())
+	}
+
+	time.Sleep(4 * time.Second)
+	if lc.GetLen() != 2 {
+		t.Fatalf("len %d, snapshot keys should expire", lc.GetLen())
+	}
+}
@@ -0,0 +1,72 @@
+package test
+
+import (
+	localcache "github.com/daqnext/go-fast-cache"
+	"github.com/daqnext/go-fast-cache/httpapi"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_HttpApi(t *testing.T) {
+	lc := localcache.New(log)
+	server := httptest.NewServer(httpapi.New(lc, httpapi.Config{Token: "secret"}))
+	defer server.Close()
+
+	do := func(method string, path string, body string, token string) (int, string) {
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, strings.TrimSpace(string(b))
+	}
+
+	if code, _ := do("GET", "/stats", "", ""); code != http.StatusUnauthorized {
+		t.Fatalf("no token status %d", code)
+	}
+	if code, _ := do("PUT", "/keys/user%2F1?ttl=100", `{"name":"Jack"}`, "secret"); code != http.StatusNoContent {
+		t.Fatalf("put status %d", code)
+	}
+	if code, body := do("GET", "/keys/user%2F1", "", "secret"); code != http.StatusOK || body != `{"name":"Jack"}` {
+		t.Fatalf("get = %d %s", code, body)
+	}
+	do("PUT", "/keys/other?ttl=100", `1`, "secret")
+	//the clock may tick between PUT and GET
+	if _, body := do("GET", "/keys?prefix=user", "", "secret"); body != `[{"key":"user/1","ttl":100}]` && body != `[{"key":"user/1","ttl":99}]` {
+		t.Fatalf("list = %s", body)
+	}
+	if _, body := do("GET", "/stats", "", "secret"); !strings.Contains(body, `"len":2`) {
+		t.Fatalf("stats = %s", body)
+	}
+	//a persisted key is listed with ttl -1
+	lc.Persist("other")
+	if _, body := do("GET", "/keys?prefix=other", "", "secret"); body != `[{"key":"other","ttl":-1}]` {
+		t.Fatalf("list persisted = %s", body)
+	}
+	do("DELETE", "/keys/other", "", "secret")
+	if code, _ := do("GET", "/keys/other", "", "secret"); code != http.StatusNotFound {
+		t.Fatalf("get deleted status %d", code)
+	}
+	if _, body := do("POST", "/flush", "", "secret"); body != `{"flushed":1}` {
+		t.Fatalf("flush = %s", body)
+	}
+
+	//limit lists the first keys in order
+	for i := 9; i >= 0; i-- {
+		lc.Set("k"+strconv.Itoa(i), i, 100)
+	}
+	for i := 0; i < 3; i++ {
+		if _, body := do("GET", "/keys?prefix=k&limit=2", "", "secret"); !strings.HasPrefix(body, `[{"key":"k0",`) || !strings.Contains(body, `{"key":"k1",`) || strings.Count(body, "key") != 2 {
+			t.Fatalf("list with limit = %s", body)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	localcache "github.com/daqnext/go-fast-cache"
+	"github.com/daqnext/go-fast-cache/invalidation"
+	"net"
+	"testing"
+	"time"
+)
+
+func Test_InvalidationBus(t *testing.T) {
+	hub := invalidation.NewMemoryHub()
+	caches := make([]*localcache.LocalCache, 3)
+	buses := make([]*invalidation.Bus, 3)
+	for i := range caches {
+		caches[i] = localcache.New(log)
+		bus, err := invalidation.New(caches[i], hub.Transport())
+		if err != nil {
+			t.Fatal(err)
+		}
+		buses[i] = bus
+		caches[i].Set("user:1", "a", 300)
+		caches[i].Set("user:2", "b", 300)
+		caches[i].Set("product:1", "c", 300)
+	}
+
+	buses[0].Delete("user:1")
+	for i, lc := range caches {
+		if _, _, exist := lc.Get("user:1"); exist {
+			t.Fatalf("cache %d still has user:1", i)
+		}
+	}
+
+	buses[1].DeleteByPrefix("user:")
+	for i, lc := range caches {
+		if lc.GetLen() != 1 {
+			t.Fatalf("cache %d len %d, want 1", i, lc.GetLen())
+		}
+	}
+
+	buses[2].Flush()
+	for i, lc := range caches {
+		if lc.GetLen() != 0 {
+			t.Fatalf("cache %d len %d, want 0", i, lc.GetLen())
+		}
+	}
+}
+
+func Test_InvalidationDedup(t *testing.T) {
+	hub := invalidation.NewMemoryHub()
+	lc := localcache.New(log)
+	bus, _ := invalidation.New(lc, hub.Transport())
+
+	//events of other origins are applied once, self-originated events are dropped
+	other := hub.Transport()
+	e := invalidation.Event{ID: "x-1", Origin: "x", Op: invalidation.OpDelete, Key: "a"}
+	lc.Set("a", 1, 300)
+	other.Publish(e)
+	if _, _, exist := lc.Get("a"); exist {
+		t.Fatal("a should be deleted")
+	}
+	lc.Set("a", 1, 300)
+	other.Publish(e)
+	if _, _, exist := lc.Get("a"); !exist {
+		t.Fatal("duplicated event should be dropped")
+	}
+	other.Publish(invalidation.Event{ID: "y-1", Origin: bus.Origin(), Op: invalidation.OpDelete, Key: "a"})
+	if _, _, exist := lc.Get("a"); !exist {
+		t.Fatal("self-originated event should be dropped")
+	}
+}
+
+func Test_MulticastInterface(t *testing.T) {
+	ifaces, _ := net.Interfaces()
+	name := ""
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp != 0 && iface.Flags&net.FlagMulticast != 0 && iface.Flags&net.FlagLoopback == 0 {
+			name = iface.Name
+			break
+		}
+	}
+	if name == "" {
+		t.Skip("no multicast interface")
+	}
+	//events are sent on the interface they are received on
+	receiver, err := invalidation.NewMulticastTransport("239.0.0.1:9999", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer receiver.Close()
+	sender, err := invalidation.NewMulticastTransport("239.0.0.1:9999", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Close()
+
+	received := make(chan invalidation.Event, 1)
+	receiver.Subscribe(func(e invalidation.Event) {
+		select {
+		case received <- e:
+		default:
+		}
+	})
+	if err := sender.Publish(invalidation.Event{ID: "1", Origin: "sender", Op: invalidation.OpDelete, Key: "k"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-received:
+		if e.Key != "k" {
+			t.Fatalf("event %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("event not received")
+	}
+}
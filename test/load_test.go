@@ -0,0 +1,162 @@
+package test
+
+import (
+	localcache "github.com/daqnext/go-fast-cache"
+	"github.com/daqnext/go-fast-cache/supervisor"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_TTLJitter(t *testing.T) {
+	lc := localcache.New(log)
+	lc.SetTTLJitter(0.2)
+	ttls := make(map[int64]bool)
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		lc.Set(key, i, 100)
+		ttl, _ := lc.TTL(key)
+		if ttl < 80 || ttl > 120 {
+			t.Fatalf("ttl %d out of jitter range", ttl)
+		}
+		ttls[ttl] = true
+	}
+	if len(ttls) < 10 {
+		t.Fatalf("only %d distinct ttls", len(ttls))
+	}
+}
+
+// Test_ConfigConcurrent changes the runtime config during Sets and loads, run with -race
+func Test_ConfigConcurrent(t *testing.T) {
+	lc := localcache.New(log)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			lc.SetTTLJitter(float64(i%10) / 10)
+			lc.SetTTLJitterSecond(int64(i % 10))
+			lc.SetEarlyExpirationBeta(float64(i % 3))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			key := strconv.Itoa(i % 10)
+			lc.Set(key, i, 100)
+			lc.GetOrLoad(key+"l", 100, func() (interface{}, error) {
+				return i, nil
+			})
+		}
+	}()
+	wg.Wait()
+}
+
+func Test_GetOrLoad(t *testing.T) {
+	lc := localcache.New(log)
+	var calls int32
+	loader := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(100 * time.Millisecond)
+		return "loaded", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := lc.GetOrLoad("a", 300, loader)
+			if err != nil || v.(string) != "loaded" {
+				t.Errorf("GetOrLoad = %v %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Fatalf("loader called %d times, want 1", calls)
+	}
+	//the clock may tick after the load
+	if _, ttl, _ := lc.Get("a"); ttl < 299 || ttl > 300 {
+		t.Fatalf("loaded ttl %d", ttl)
+	}
+}
+
+func Test_GetOrLoadEarlyExpiration(t *testing.T) {
+	lc := localcache.New(log)
+	var calls int32
+	loader := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(10 * time.Millisecond)
+		return "loaded", nil
+	}
+
+	lc.SetEarlyExpirationBeta(0)
+	lc.GetOrLoad("a", 2, loader)
+	for i := 0; i < 100; i++ {
+		lc.GetOrLoad("a", 2, loader)
+	}
+	if calls != 1 {
+		t.Fatalf("loader called %d times without early expiration, want 1", calls)
+	}
+
+	//a big beta makes a key which expires in 2 seconds reload almost surely
+	lc.SetEarlyExpirationBeta(1000)
+	for i := 0; i < 10; i++ {
+		lc.GetOrLoad("a", 2, loader)
+	}
+	if calls < 2 {
+		t.Fatal("key should be reloaded before it expires")
+	}
+}
+
+func Test_GetOrLoadPanic(t *testing.T) {
+	lc := localcache.New(log)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loader := func() (interface{}, error) {
+		close(started)
+		<-release
+		panic("loader failed")
+	}
+
+	panicked := make(chan interface{})
+	go func() {
+		defer func() {
+			panicked <- recover()
+		}()
+		lc.GetOrLoad("a", 300, loader)
+	}()
+	<-started
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := lc.GetOrLoad("a", 300, func() (interface{}, error) {
+				return "not shared", nil
+			})
+			if pe, ok := err.(*supervisor.PanicError); !ok || pe.Value != "loader failed" || v != nil {
+				t.Errorf("waiting call = %v %v, want PanicError", v, err)
+			}
+		}()
+	}
+	//let the waiting calls join the loader call
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if r := <-panicked; r != "loader failed" {
+		t.Fatalf("starting call recovered %v", r)
+	}
+	if _, _, exist := lc.Get("a"); exist {
+		t.Fatal("panicked load should store nothing")
+	}
+
+	//the next call loads again
+	if v, err := lc.GetOrLoad("a", 300, func() (interface{}, error) { return 1, nil }); err != nil || v != 1 {
+		t.Fatalf("GetOrLoad after panic = %v %v", v, err)
+	}
+}
@@ -1,11 +1,12 @@
 package test
 
 import (
-	locallog "github.com/daqnext/LocalLog/log"
 	localcache "github.com/daqnext/go-fast-cache"
 	"github.com/daqnext/go-fast-cache/ttltype"
+	stdlog "log"
 	"math/rand"
 	"net/http"
+	"os"
 	"runtime"
 	"strconv"
 	"sync"
@@ -19,7 +20,17 @@ type Person struct {
 	Location string
 }
 
-var log *locallog.LocalLog
+// testLogger is a logging.Logger on the standard logger, so tests need no log directory
+type testLogger struct {
+	*stdlog.Logger
+}
+
+func (l *testLogger) Debugf(format string, args ...interface{}) { l.Printf("[DEBUG] "+format, args...) }
+func (l *testLogger) Infof(format string, args ...interface{})  { l.Printf("[INFO] "+format, args...) }
+func (l *testLogger) Warnf(format string, args ...interface{})  { l.Printf("[WARN] "+format, args...) }
+func (l *testLogger) Errorf(format string, args ...interface{}) { l.Printf("[ERROR] "+format, args...) }
+
+var log = &testLogger{stdlog.New(os.Stderr, "", stdlog.LstdFlags)}
 
 func printMemStats() {
 	var m runtime.MemStats
@@ -27,14 +38,6 @@ func printMemStats() {
 	log.Printf("Alloc = %v KB, TotalAlloc = %v KB, Sys = %v KB,Lookups = %v NumGC = %v\n", m.Alloc/1024, m.TotalAlloc/1024, m.Sys/1024, m.Lookups, m.NumGC)
 }
 
-func init() {
-	var err error
-	log, err = locallog.New("logs", 2, 20, 30)
-	if err != nil {
-		panic(err.Error())
-	}
-}
-
 func Test_main(t *testing.T) {
 	lc := localcache.New(log) // or use NewWithInterval(intervalSecond int) custom the schedule job interval
 	lc.SetCountLimit(10000)   //if not set default is 100000
@@ -439,3 +442,49 @@ func BenchmarkLocalCache_GetStruct(b *testing.B) {
 	}
 	log.Println(e)
 }
+
+func BenchmarkLocalCache_SetOverwrite(b *testing.B) {
+	lc := localcache.New(log)
+	a := &Person{"Jack", 18, "America"}
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lc.Set(keys[i%len(keys)], a, int64(i%300+1))
+	}
+}
+
+func BenchmarkLocalCache_SetNewKey(b *testing.B) {
+	lc := localcache.New(log)
+	a := &Person{"Jack", 18, "America"}
+	keys := make([]string, b.N)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lc.Set(keys[i], a, 300)
+	}
+}
+
+func BenchmarkLocalCache_SetParallel(b *testing.B) {
+	lc := localcache.New(log)
+	a := &Person{"Jack", 18, "America"}
+	keys := make([]string, 10000)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := rand.Intn(len(keys))
+		for pb.Next() {
+			lc.Set(keys[i%len(keys)], a, int64(i%300+1))
+			i++
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package test
+
+import (
+	"fmt"
+	localcache "github.com/daqnext/go-fast-cache"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordLogger keeps the formatted lines it receives
+type recordLogger struct {
+	lock  sync.Mutex
+	lines []string
+}
+
+func (l *recordLogger) record(level string, format string, args []interface{}) {
+	l.lock.Lock()
+	l.lines = append(l.lines, level+" "+fmt.Sprintf(format, args...))
+	l.lock.Unlock()
+}
+
+func (l *recordLogger) Debugf(format string, args ...interface{}) { l.record("DEBUG", format, args) }
+func (l *recordLogger) Infof(format string, args ...interface{})  { l.record("INFO", format, args) }
+func (l *recordLogger) Warnf(format string, args ...interface{})  { l.record("WARN", format, args) }
+func (l *recordLogger) Errorf(format string, args ...interface{}) { l.record("ERROR", format, args) }
+
+func (l *recordLogger) contains(s string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, line := range l.lines {
+		if strings.Contains(line, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func Test_NilLogger(t *testing.T) {
+	lc := localcache.NewWithInterval(1, nil)
+	lc.SetCountLimit(10000)
+	lc.SetEvictionWatermark(0.5, 0.4)
+	for i := 0; i < 6000; i++ {
+		lc.Set(strconv.Itoa(i), i, 1)
+	}
+	time.Sleep(2500 * time.Millisecond)
+	if lc.GetLen() != 0 {
+		t.Fatalf("len %d", lc.GetLen())
+	}
+}
+
+func Test_LoggerEvictionPass(t *testing.T) {
+	rl := &recordLogger{}
+	lc := localcache.New(rl)
+	lc.SetCountLimit(10000)
+	lc.SetEvictionWatermark(0.5, 0.4)
+	for i := 0; i < 6000; i++ {
+		lc.Set(strconv.Itoa(i), i, 100)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if !rl.contains("INFO eviction pass evicted") {
+		t.Fatalf("eviction pass not logged: %v", rl.lines)
+	}
+}
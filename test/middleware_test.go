@@ -0,0 +1,151 @@
+package test
+
+import (
+	"context"
+	localcache "github.com/daqnext/go-fast-cache"
+	"strings"
+	"testing"
+)
+
+// lowerKeys normalizes keys to lower case
+type lowerKeys struct {
+	localcache.Handler
+}
+
+func (h lowerKeys) Get(ctx context.Context, key string) (interface{}, int64, bool) {
+	return h.Handler.Get(ctx, strings.ToLower(key))
+}
+
+func (h lowerKeys) Set(ctx context.Context, key string, value interface{}, ttlSecond int64) error {
+	return h.Handler.Set(ctx, strings.ToLower(key), value, ttlSecond)
+}
+
+func (h lowerKeys) Delete(ctx context.Context, key string) {
+	h.Handler.Delete(ctx, strings.ToLower(key))
+}
+
+// traced appends the name of the middleware to calls on every call
+type traced struct {
+	localcache.Handler
+	name  string
+	calls *[]string
+}
+
+func (h traced) Get(ctx context.Context, key string) (interface{}, int64, bool) {
+	*h.calls = append(*h.calls, h.name+" get")
+	return h.Handler.Get(ctx, key)
+}
+
+func (h traced) Set(ctx context.Context, key string, value interface{}, ttlSecond int64) error {
+	*h.calls = append(*h.calls, h.name+" set")
+	return h.Handler.Set(ctx, key, value, ttlSecond)
+}
+
+func (h traced) Delete(ctx context.Context, key string) {
+	*h.calls = append(*h.calls, h.name+" delete")
+	h.Handler.Delete(ctx, key)
+}
+
+// sealed is the stored form of values written through sealValues, like an encrypted value
+type sealed struct {
+	v interface{}
+}
+
+type sealValues struct {
+	localcache.Handler
+}
+
+func (h sealValues) Get(ctx context.Context, key string) (interface{}, int64, bool) {
+	value, ttl, exist := h.Handler.Get(ctx, key)
+	if !exist {
+		return nil, 0, false
+	}
+	return value.(sealed).v, ttl, true
+}
+
+func (h sealValues) Set(ctx context.Context, key string, value interface{}, ttlSecond int64) error {
+	return h.Handler.Set(ctx, key, sealed{value}, ttlSecond)
+}
+
+func Test_Middleware(t *testing.T) {
+	lc := localcache.New(log)
+	calls := []string{}
+	trace := func(name string) localcache.Middleware {
+		return func(next localcache.Handler) localcache.Handler {
+			return traced{Handler: next, name: name, calls: &calls}
+		}
+	}
+	lc.Use(trace("a"), trace("b"))
+	lc.Use(func(next localcache.Handler) localcache.Handler {
+		return lowerKeys{next}
+	})
+
+	lc.Set("Foo", 1, 10)
+	if v, _, exist := lc.Get("FOO"); !exist || v != 1 {
+		t.Fatalf("get %v %v", v, exist)
+	}
+	if _, exist := lc.TTL("foo"); !exist {
+		t.Fatal("stored key is not normalized")
+	}
+	lc.Delete("fOO")
+	if _, _, exist := lc.Get("foo"); exist {
+		t.Fatal("delete")
+	}
+	want := "a set,b set,a get,b get,a delete,b delete,a get,b get"
+	if strings.Join(calls, ",") != want {
+		t.Fatalf("calls %v", calls)
+	}
+}
+
+func Test_MiddlewareCtxVariants(t *testing.T) {
+	lc := localcache.New(log)
+	lc.Use(func(next localcache.Handler) localcache.Handler {
+		return lowerKeys{next}
+	}, func(next localcache.Handler) localcache.Handler {
+		return sealValues{next}
+	})
+	ctx := context.Background()
+
+	if err := lc.SetCtx(ctx, "A", "a", 10); err != nil {
+		t.Fatal(err)
+	}
+	if v, _, exist := lc.GetCtx(ctx, "a"); !exist || v != "a" {
+		t.Fatalf("GetCtx = %v %v", v, exist)
+	}
+	lc.SetWithTags("B", "b", 10, "t")
+	lc.SetSliding("C", "c", 10)
+	for _, key := range []string{"b", "C"} {
+		if v, _, exist := lc.Get(key); !exist || v != strings.ToLower(key) {
+			t.Fatalf("Get %s = %v %v", key, v, exist)
+		}
+	}
+	if tags := lc.GetTags("b"); len(tags) != 1 {
+		t.Fatalf("tags of the stored key %v", tags)
+	}
+	//GetOrLoad reads and stores through the chain
+	v, err := lc.GetOrLoad("A", 10, func() (interface{}, error) {
+		t.Fatal("loader called for a stored key")
+		return nil, nil
+	})
+	if err != nil || v != "a" {
+		t.Fatalf("GetOrLoad = %v %v", v, err)
+	}
+	lc.GetOrLoad("D", 10, func() (interface{}, error) {
+		return "d", nil
+	})
+	if v, _, _ := lc.Get("d"); v != "d" {
+		t.Fatalf("loaded value %v", v)
+	}
+	lc.DeleteCtx(ctx, "D")
+	if _, exist := lc.TTL("d"); exist {
+		t.Fatal("DeleteCtx")
+	}
+
+	//every value is stored sealed
+	lc.Range(func(key string, value interface{}, ttl int64) bool {
+		if _, ok := value.(sealed); !ok || key != strings.ToLower(key) {
+			t.Fatalf("stored %s = %v", key, value)
+		}
+		return true
+	})
+}
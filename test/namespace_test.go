@@ -0,0 +1,89 @@
+package test
+
+import (
+	localcache "github.com/daqnext/go-fast-cache"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_Namespace(t *testing.T) {
+	lc := localcache.NewWithInterval(1, log)
+	users := lc.Namespace("user")
+	orders := lc.Namespace("order")
+	if lc.Namespace("user") != users {
+		t.Fatal("Namespace should return the same instance")
+	}
+
+	for i := 0; i < 10; i++ {
+		users.Set(strconv.Itoa(i), i, 300)
+		orders.Set(strconv.Itoa(i), i, 1)
+	}
+	users.Set("0", 0, 300) //overwrite
+	if v, _, _ := lc.Get("user:3"); v.(int) != 3 {
+		t.Fatal("namespace key should be prefixed")
+	}
+	if users.Len() != 10 || orders.Len() != 10 || lc.GetLen() != 20 {
+		t.Fatalf("len users %d orders %d all %d", users.Len(), orders.Len(), lc.GetLen())
+	}
+
+	users.Delete("1")
+	users.Get("1")
+	users.Get("2")
+	stats := users.Stats()
+	if stats.Len != 9 || stats.Hits != 1 || stats.Misses != 1 || stats.Sets != 11 || stats.Deletes != 1 {
+		t.Fatalf("stats %+v", stats)
+	}
+
+	//expired keys are removed from the namespace count
+	time.Sleep(3 * time.Second)
+	if orders.Len() != 0 {
+		t.Fatalf("orders len %d after expire", orders.Len())
+	}
+
+	if n := users.Flush(); n != 9 || users.Len() != 0 {
+		t.Fatalf("flushed %d, len %d", n, users.Len())
+	}
+
+	users.SetQuota(2)
+	users.Set("a", 1, 300)
+	users.Set("b", 1, 300)
+	if users.Set("c", 1, 300) {
+		t.Fatal("set over quota should be rejected")
+	}
+	if !users.Set("a", 2, 300) {
+		t.Fatal("overwrite within quota should be accepted")
+	}
+	if users.Stats().Rejected != 1 {
+		t.Fatalf("rejected %d", users.Stats().Rejected)
+	}
+}
+
+func Test_NamespaceQuotaConcurrent(t *testing.T) {
+	lc := localcache.New(log)
+	users := lc.Namespace("user")
+	users.SetQuota(10)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			users.Set(strconv.Itoa(i), i, 300)
+		}(i)
+	}
+	wg.Wait()
+	if stats := users.Stats(); stats.Len != 10 || stats.Rejected != 90 {
+		t.Fatalf("stats %+v", stats)
+	}
+}
+
+func Test_NamespaceName(t *testing.T) {
+	lc := localcache.New(log)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("name with separator should panic")
+		}
+	}()
+	lc.Namespace("a" + localcache.NamespaceSeparator + "b")
+}
@@ -0,0 +1,154 @@
+package test
+
+import (
+	localcache "github.com/daqnext/go-fast-cache"
+	"github.com/daqnext/go-fast-cache/otp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_OtpIssueVerify(t *testing.T) {
+	m := otp.New(localcache.New(log), otp.Config{MaxAttempts: 3})
+	code, err := m.Issue("alice", 10)
+	if err != nil || len(code) != otp.DefaultLength || strings.Trim(code, localcache.AlphabetDigits) != "" {
+		t.Fatalf("code %q err %v", code, err)
+	}
+	if _, err := m.Issue("alice", 10); err != otp.ErrCooldown {
+		t.Fatalf("resend err %v", err)
+	}
+	if err := m.Verify("bob", code); err != otp.ErrNotFound {
+		t.Fatalf("other subject err %v", err)
+	}
+	if err := m.Verify("alice", code+"0"); err != otp.ErrMismatch {
+		t.Fatalf("wrong code err %v", err)
+	}
+	if err := m.Verify("alice", code); err != nil {
+		t.Fatalf("right code err %v", err)
+	}
+	//single use
+	if err := m.Verify("alice", code); err != otp.ErrNotFound {
+		t.Fatalf("reused code err %v", err)
+	}
+	//cooldown outlives the use of the code
+	if _, err := m.Issue("alice", 10); err != otp.ErrCooldown {
+		t.Fatalf("resend after use err %v", err)
+	}
+	m.Revoke("alice")
+	if _, err := m.Issue("alice", 10); err != nil {
+		t.Fatalf("issue after revoke err %v", err)
+	}
+	if _, err := m.Issue("alice", 0); err != otp.ErrTTL {
+		t.Fatalf("zero ttl err %v", err)
+	}
+}
+
+func Test_OtpLockout(t *testing.T) {
+	m := otp.New(localcache.New(log), otp.Config{MaxAttempts: 3, ResendCooldownSecond: -1})
+	code, _ := m.Issue("alice", 10)
+	for i := 0; i < 2; i++ {
+		if err := m.Verify("alice", "x"); err != otp.ErrMismatch {
+			t.Fatalf("attempt %d err %v", i, err)
+		}
+	}
+	if err := m.Verify("alice", "x"); err != otp.ErrLocked {
+		t.Fatalf("last attempt err %v", err)
+	}
+	if err := m.Verify("alice", code); err != otp.ErrLocked {
+		t.Fatalf("right code when locked err %v", err)
+	}
+	if _, err := m.Issue("alice", 10); err != otp.ErrLocked {
+		t.Fatalf("issue when locked err %v", err)
+	}
+	//a new code resets the attempts
+	code, _ = m.Issue("bob", 10)
+	m.Verify("bob", "x")
+	m.Verify("bob", "x")
+	code, _ = m.Issue("bob", 10)
+	m.Verify("bob", "x")
+	if err := m.Verify("bob", code); err != nil {
+		t.Fatalf("right code after reissue err %v", err)
+	}
+}
+
+func Test_OtpExpire(t *testing.T) {
+	m := otp.New(localcache.New(log), otp.Config{ResendCooldownSecond: -1})
+	code, _ := m.Issue("alice", 1)
+	time.Sleep(2100 * time.Millisecond)
+	if err := m.Verify("alice", code); err != otp.ErrNotFound {
+		t.Fatalf("expired code err %v", err)
+	}
+}
+
+func Test_OtpConcurrent(t *testing.T) {
+	m := otp.New(localcache.New(log), otp.Config{MaxAttempts: 5})
+	code, _ := m.Issue("alice", 10)
+	wrong, _ := m.Issue("bob", 10)
+	wrong += "0"
+
+	var lock sync.Mutex
+	errs := map[error]int{}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			err := m.Verify("alice", code)
+			lock.Lock()
+			errs[err]++
+			lock.Unlock()
+		}()
+		go func() {
+			defer wg.Done()
+			err := m.Verify("bob", wrong)
+			lock.Lock()
+			errs[err]++
+			lock.Unlock()
+		}()
+	}
+	wg.Wait()
+	if errs[nil] != 1 || errs[otp.ErrNotFound] != 49 {
+		t.Fatalf("right code results %v", errs)
+	}
+	if errs[otp.ErrMismatch] != 4 || errs[otp.ErrLocked] != 46 {
+		t.Fatalf("wrong code results %v", errs)
+	}
+}
+
+func Test_OtpMiddleware(t *testing.T) {
+	lc := localcache.New(log)
+	lc.Use(func(next localcache.Handler) localcache.Handler {
+		return lowerKeys{next}
+	}, func(next localcache.Handler) localcache.Handler {
+		return sealValues{next}
+	})
+	m := otp.New(lc, otp.Config{})
+	code, err := m.Issue("Alice", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Verify("Alice", code); err != nil {
+		t.Fatalf("verify through the chain err %v", err)
+	}
+	m.Revoke("Alice")
+	if _, err := m.Issue("Alice", 10); err != nil {
+		t.Fatalf("issue after revoke err %v", err)
+	}
+}
+
+func Test_OtpLongLockout(t *testing.T) {
+	lc := localcache.New(log)
+	m := otp.New(lc, otp.Config{MaxAttempts: 1, LockoutSecond: 3 * localcache.MaxTTLSecond})
+	m.Issue("alice", 10)
+	if err := m.Verify("alice", "x"); err != otp.ErrLocked {
+		t.Fatalf("err %v", err)
+	}
+	//the lockout is clamped to the ttl the state can be stored with
+	if ttl, _ := lc.TTL(otp.DefaultKeyPrefix + "alice"); ttl < localcache.MaxTTLSecond-1 {
+		t.Fatalf("state ttl %d", ttl)
+	}
+	if _, err := m.Issue("alice", 10); err != otp.ErrLocked {
+		t.Fatalf("issue when locked err %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	localcache "github.com/daqnext/go-fast-cache"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_RandFormat(t *testing.T) {
+	lc := localcache.New(log)
+	if token := lc.SetRand("a", 10); len(token) != localcache.DefaultRandLength || strings.Trim(token, localcache.AlphabetLetters) != "" {
+		t.Fatalf("default token %q", token)
+	}
+	for _, alphabet := range []string{localcache.AlphabetDigits, localcache.AlphabetBase32, localcache.AlphabetURLSafe} {
+		lc.SetRandFormat(6, alphabet)
+		seen := map[string]bool{}
+		for i := 0; i < 100; i++ {
+			token := lc.SetRand("a", 10)
+			if len(token) != 6 || strings.Trim(token, alphabet) != "" {
+				t.Fatalf("token %q of %s", token, alphabet)
+			}
+			seen[token] = true
+		}
+		if len(seen) < 90 {
+			t.Fatalf("%d distinct tokens of 100", len(seen))
+		}
+	}
+	//invalid format uses the defaults
+	lc.SetRandFormat(0, "x")
+	if token := lc.SetRand("a", 10); len(token) != localcache.DefaultRandLength || strings.Trim(token, localcache.AlphabetLetters) != "" {
+		t.Fatalf("token %q", token)
+	}
+}
+
+func Test_VerifyRand(t *testing.T) {
+	lc := localcache.New(log)
+	token := lc.SetRand("code", 10)
+	if lc.VerifyRand("code", token+"x") || lc.VerifyRand("code", "") || lc.VerifyRand("missing", token) {
+		t.Fatal("wrong token verified")
+	}
+	if lc.GetRand("code") != token {
+		t.Fatal("failed verify deleted the token")
+	}
+	if !lc.VerifyRand("code", token) {
+		t.Fatal("verify")
+	}
+	if lc.VerifyRand("code", token) {
+		t.Fatal("token verified twice")
+	}
+
+	lc.Set("expired", "abc", 1)
+	time.Sleep(2100 * time.Millisecond)
+	if lc.VerifyRand("expired", "abc") {
+		t.Fatal("expired token verified")
+	}
+
+	//one of concurrent verifies succeeds
+	token = lc.SetRand("code", 10)
+	var ok int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if lc.VerifyRand("code", token) {
+				atomic.AddInt32(&ok, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if ok != 1 {
+		t.Fatalf("%d verifies succeeded", ok)
+	}
+}
+
+func Test_VerifyRandMiddleware(t *testing.T) {
+	lc := localcache.New(log)
+	lc.Use(func(next localcache.Handler) localcache.Handler {
+		return lowerKeys{next}
+	}, func(next localcache.Handler) localcache.Handler {
+		return sealValues{next}
+	})
+	token := lc.SetRand("Code", 10)
+	if lc.GetRand("CODE") != token {
+		t.Fatal("GetRand through the chain")
+	}
+	if lc.VerifyRand("Code", token+"x") {
+		t.Fatal("wrong token verified")
+	}
+	if !lc.VerifyRand("Code", token) {
+		t.Fatal("verify through the chain")
+	}
+	if lc.VerifyRand("code", token) || lc.GetRand("code") != "" {
+		t.Fatal("token verified twice")
+	}
+}
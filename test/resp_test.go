@@ -0,0 +1,200 @@
+package test
+
+import (
+	"bufio"
+	localcache "github.com/daqnext/go-fast-cache"
+	"github.com/daqnext/go-fast-cache/resp"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func respCall(t *testing.T, rw *bufio.ReadWriter, args ...string) string {
+	rw.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		rw.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n")
+	}
+	if err := rw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	line, err := rw.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+	switch line[0] {
+	case '$':
+		if line == "$-1" {
+			return "(nil)"
+		}
+		body, _ := rw.ReadString('\n')
+		return strings.TrimRight(body, "\r\n")
+	case '*':
+		items := make([]string, 0)
+		n, _ := strconv.Atoi(line[1:])
+		for i := 0; i < n; i++ {
+			rw.ReadString('\n')
+			body, _ := rw.ReadString('\n')
+			items = append(items, strings.TrimRight(body, "\r\n"))
+		}
+		return strings.Join(items, ",")
+	default:
+		return line
+	}
+}
+
+func Test_RespServer(t *testing.T) {
+	lc := localcache.New(log)
+	server := resp.NewServer(lc, log)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(l)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	cases := [][]string{
+		{"+PONG", "PING"},
+		{"+OK", "SET", "foo", "bar", "EX", "100"},
+		{"bar", "GET", "foo"},
+		{":1", "EXPIRE", "foo", "50"},
+		{":1", "INCR", "counter"},
+		{":2", "INCR", "counter"},
+		{"-ERR value is not an integer or out of range", "INCR", "foo"},
+		{"foo", "KEYS", "f*"},
+		{":2", "DBSIZE"},
+		{":1", "DEL", "foo", "none"},
+		{"(nil)", "GET", "foo"},
+		{":-2", "TTL", "foo"},
+	}
+	for _, c := range cases {
+		if got := respCall(t, rw, c[1:]...); got != c[0] {
+			t.Fatalf("%v = %q, want %q", c[1:], got, c[0])
+		}
+	}
+
+	//the clock may tick between SET and TTL
+	respCall(t, rw, "SET", "foo", "bar", "EX", "100")
+	if ttl := respCall(t, rw, "TTL", "foo"); ttl != ":100" && ttl != ":99" {
+		t.Fatalf("TTL after SET EX 100 = %q", ttl)
+	}
+	//like redis, SET without EX and INCR of a new key never expire
+	cases = [][]string{
+		{"+OK", "SET", "foo", "bar"},
+		{":-1", "TTL", "foo"},
+		{":-1", "TTL", "counter"},
+		{":1", "INCR", "created"},
+		{":-1", "TTL", "created"},
+	}
+	for _, c := range cases {
+		if got := respCall(t, rw, c[1:]...); got != c[0] {
+			t.Fatalf("%v = %q, want %q", c[1:], got, c[0])
+		}
+	}
+}
+
+func Test_RespNullArray(t *testing.T) {
+	server := resp.NewServer(localcache.New(log), log)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(l)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	//null and empty arrays are empty commands, the connection keeps serving
+	rw.WriteString("*-1\r\n*0\r\n")
+	if got := respCall(t, rw, "PING"); got != "+PONG" {
+		t.Fatalf("PING after null array = %q", got)
+	}
+}
+
+func Test_RespKeys(t *testing.T) {
+	lc := localcache.New(log)
+	server := resp.NewServer(lc, log)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(l)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	for _, key := range []string{"user/1", "user/22", "vser/1", "a*b", "axb"} {
+		lc.Set(key, "1", 100)
+	}
+	cases := [][]string{
+		{"*", "a*b,axb,user/1,user/22,vser/1"},
+		{"user/?", "user/1"},
+		{"user*2", "user/22"},
+		{"[uv]ser/1", "user/1,vser/1"},
+		{"[^u]ser/*", "vser/1"},
+		{"[t-v]ser/22", "user/22"},
+		{`a\*b`, "a*b"},
+		{"a[*]b", "a*b"},
+		{"user", ""},
+	}
+	for _, c := range cases {
+		got := strings.Split(respCall(t, rw, "KEYS", c[0]), ",")
+		sort.Strings(got)
+		if strings.Join(got, ",") != c[1] {
+			t.Fatalf("KEYS %s = %v, want %s", c[0], got, c[1])
+		}
+	}
+}
+
+func Test_RespBulk(t *testing.T) {
+	server := resp.NewServer(localcache.New(log), log)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(l)
+	defer server.Close()
+
+	//a client declaring a huge bulk string and sending a few bytes is disconnected when it closes
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Write([]byte("*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$500000000\r\nabc"))
+	conn.Close()
+
+	conn, err = net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	value := strings.Repeat("v", 200*1024)
+	if got := respCall(t, rw, "SET", "big", value); got != "+OK" {
+		t.Fatalf("SET big = %q", got)
+	}
+	if got := respCall(t, rw, "GET", "big"); got != value {
+		t.Fatalf("GET big returned %d bytes", len(got))
+	}
+	if got := respCall(t, rw, "GET", "k"); got != "(nil)" {
+		t.Fatalf("GET k = %q", got)
+	}
+}
@@ -0,0 +1,34 @@
+package test
+
+import (
+	localcache "github.com/daqnext/go-fast-cache"
+	"testing"
+	"time"
+)
+
+func Test_SlidingExpiration(t *testing.T) {
+	lc := localcache.New(log)
+	lc.SetSliding("session", "s", 3)
+	lc.Set("plain", "p", 3)
+
+	//read every second, the sliding key stays alive
+	for i := 0; i < 5; i++ {
+		time.Sleep(time.Second)
+		if _, _, exist := lc.Get("session"); !exist {
+			t.Fatalf("session expired after %d seconds", i+1)
+		}
+		lc.Get("plain")
+	}
+	if _, _, exist := lc.Get("plain"); exist {
+		t.Fatal("plain key should be expired")
+	}
+
+	lc.SetSlidingExpiration(true)
+	lc.Set("all", "a", 3)
+	for i := 0; i < 5; i++ {
+		time.Sleep(time.Second)
+		if _, _, exist := lc.Get("all"); !exist {
+			t.Fatalf("key expired after %d seconds with cache sliding expiration", i+1)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	localcache "github.com/daqnext/go-fast-cache"
+	"github.com/daqnext/go-fast-cache/supervisor"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_SupervisorRestart(t *testing.T) {
+	sup := supervisor.New(log)
+	sup.SetBackoff(10*time.Millisecond, 40*time.Millisecond)
+	var lock sync.Mutex
+	reported := []string{}
+	sup.SetOnError(func(job string, err error) {
+		lock.Lock()
+		reported = append(reported, job+" "+err.Error())
+		lock.Unlock()
+	})
+
+	runs := int32(0)
+	done := make(chan struct{})
+	sup.Go("flaky", func() {
+		if atomic.AddInt32(&runs, 1) <= 3 {
+			panic("boom")
+		}
+		<-done
+	})
+	time.Sleep(300 * time.Millisecond)
+
+	h := sup.Health()
+	if !h.Alive || len(h.Workers) != 1 {
+		t.Fatalf("health %+v", h)
+	}
+	w := h.Workers[0]
+	if w.Name != "flaky" || w.Restarts != 3 || w.Errors != 3 || w.LastError == nil {
+		t.Fatalf("worker %+v", w)
+	}
+	lock.Lock()
+	if len(reported) != 3 || reported[0] != "flaky panic: boom" {
+		t.Fatalf("reported %v", reported)
+	}
+	lock.Unlock()
+
+	//a returned job is not alive
+	close(done)
+	time.Sleep(50 * time.Millisecond)
+	if h := sup.Health(); h.Alive || h.Workers[0].Alive {
+		t.Fatalf("health after return %+v", h)
+	}
+}
+
+func Test_SupervisorHookPanic(t *testing.T) {
+	sup := supervisor.New(nil)
+	sup.SetBackoff(time.Millisecond, time.Millisecond)
+	sup.SetOnError(func(job string, err error) {
+		panic("hook")
+	})
+	runs := int32(0)
+	sup.Go("job", func() {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			panic("boom")
+		}
+		select {}
+	})
+	time.Sleep(50 * time.Millisecond)
+	if h := sup.Health(); !h.Alive || h.Workers[0].Restarts != 1 {
+		t.Fatalf("health %+v", h)
+	}
+}
+
+func Test_LocalCacheHealth(t *testing.T) {
+	lc := localcache.New(log)
+	h := lc.Health()
+	if !h.Alive || len(h.Workers) != 4 {
+		t.Fatalf("health %+v", h)
+	}
+	names := map[string]bool{}
+	for _, w := range h.Workers {
+		names[w.Name] = true
+	}
+	for _, name := range []string{"sortedset", "scheduleDeleteExpire", "scheduleDeleteOverLimit", "scheduleSlide"} {
+		if !names[name] {
+			t.Fatalf("worker %s missing in %+v", name, h)
+		}
+	}
+}
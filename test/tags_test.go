@@ -0,0 +1,84 @@
+package test
+
+import (
+	localcache "github.com/daqnext/go-fast-cache"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_InvalidateTag(t *testing.T) {
+	lc := localcache.New(log)
+	lc.SetWithTags("page:1", "p1", 300, "product:1", "price")
+	lc.SetWithTags("page:2", "p2", 300, "product:2", "price")
+	lc.SetWithTags("page:3", "p3", 300, "product:3")
+	lc.Set("other", "o", 300)
+
+	if n := lc.InvalidateTag("price"); n != 2 {
+		t.Fatalf("InvalidateTag removed %d keys, want 2", n)
+	}
+	if _, _, exist := lc.Get("page:1"); exist {
+		t.Fatal("page:1 should be removed")
+	}
+	if _, _, exist := lc.Get("page:3"); !exist {
+		t.Fatal("page:3 should exist")
+	}
+	if n := lc.InvalidateTag("product:1"); n != 0 {
+		t.Fatalf("InvalidateTag removed %d keys, want 0", n)
+	}
+
+	//overwrite without tags drops the old tags
+	lc.Set("page:3", "p3", 300)
+	if tags := lc.GetTags("page:3"); len(tags) != 0 {
+		t.Fatalf("page:3 tags %v, want none", tags)
+	}
+	if n := lc.InvalidateTag("product:3"); n != 0 {
+		t.Fatalf("InvalidateTag removed %d keys, want 0", n)
+	}
+}
+
+func Test_TagExpire(t *testing.T) {
+	lc := localcache.NewWithInterval(1, log)
+	lc.SetWithTags("a", 1, 1, "t")
+	time.Sleep(3 * time.Second)
+	if tags := lc.GetTags("a"); len(tags) != 0 {
+		t.Fatalf("expired key still has tags %v", tags)
+	}
+}
+
+func Test_TagConcurrentOverwrite(t *testing.T) {
+	lc := localcache.New(log)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 2000; j++ {
+				key := strconv.Itoa(j % 10)
+				switch (i + j) % 3 {
+				case 0:
+					lc.SetWithTags(key, j, 300, "t")
+				case 1:
+					lc.Set(key, j, 300)
+				default:
+					lc.Delete(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	//untagged writes after the race leave no tags behind
+	for j := 0; j < 10; j++ {
+		lc.Set(strconv.Itoa(j), j, 300)
+	}
+	for j := 0; j < 10; j++ {
+		if tags := lc.GetTags(strconv.Itoa(j)); len(tags) != 0 {
+			t.Fatalf("key %d tags %v, want none", j, tags)
+		}
+	}
+	if n := lc.InvalidateTag("t"); n != 0 {
+		t.Fatalf("InvalidateTag removed %d untagged keys", n)
+	}
+}
@@ -0,0 +1,55 @@
+package test
+
+import (
+	"context"
+	localcache "github.com/daqnext/go-fast-cache"
+	"github.com/daqnext/go-fast-cache/tiered"
+	"github.com/daqnext/go-fast-cache/ttltype"
+	"testing"
+)
+
+func Test_TieredCache(t *testing.T) {
+	ctx := context.Background()
+	remote := tiered.NewMemoryStore()
+	lc := localcache.New(log)
+	tc := tiered.New(lc, remote, tiered.Config{LocalTTLSecond: 5})
+
+	tc.Set(ctx, "a", []byte("1"), 300)
+	if _, ttl, exist := lc.Get("a"); !exist || ttl > 5 {
+		t.Fatalf("write through local ttl %d exist %v", ttl, exist)
+	}
+	if v, _, _ := tc.Get(ctx, "a"); string(v) != "1" || remote.Gets != 0 {
+		t.Fatalf("local hit = %s, remote gets %d", v, remote.Gets)
+	}
+
+	//miss in local, hit in remote populates local
+	lc.Delete("a")
+	if v, _, _ := tc.Get(ctx, "a"); string(v) != "1" || remote.Gets != 1 {
+		t.Fatalf("remote hit = %s, remote gets %d", v, remote.Gets)
+	}
+	if _, _, exist := lc.Get("a"); !exist {
+		t.Fatal("remote hit should populate local")
+	}
+
+	tc.Delete(ctx, "a")
+	if _, exist, _ := tc.Get(ctx, "a"); exist {
+		t.Fatal("a should be deleted from both tiers")
+	}
+
+	around := tiered.New(lc, remote, tiered.Config{WriteMode: tiered.WriteAround})
+	lc.Set("b", []byte("old"), 300)
+	around.Set(ctx, "b", []byte("new"), 300)
+	if _, _, exist := lc.Get("b"); exist {
+		t.Fatal("write around should drop the local copy")
+	}
+	if v, _, _ := around.Get(ctx, "b"); string(v) != "new" {
+		t.Fatalf("get = %s", v)
+	}
+
+	if err := tc.Set(ctx, "c", []byte("1"), ttltype.Keep); err != tiered.ErrInvalidTTL {
+		t.Fatalf("set with ttltype.Keep err %v", err)
+	}
+	if _, exist, _ := tc.Get(ctx, "c"); exist {
+		t.Fatal("rejected set should store nothing")
+	}
+}
@@ -0,0 +1,52 @@
+package test
+
+import (
+	localcache "github.com/daqnext/go-fast-cache"
+	"github.com/daqnext/go-fast-cache/ttltype"
+	"testing"
+	"time"
+)
+
+func Test_TTLManipulation(t *testing.T) {
+	lc := localcache.New(log)
+	p := &Person{"Jack", 18, "London"}
+	lc.Set("a", p, 100)
+
+	if ttl, _ := lc.TTL("a"); ttl != 100 {
+		t.Fatalf("ttl %d, want 100", ttl)
+	}
+	if !lc.Expire("a", 20) {
+		t.Fatal("Expire existing key should return true")
+	}
+	if v, ttl, _ := lc.Get("a"); ttl != 20 || v.(*Person) != p {
+		t.Fatalf("after Expire ttl %d value %v", ttl, v)
+	}
+	lc.ExpireAt("a", time.Now().Add(50*time.Second))
+	if ttl, _ := lc.TTL("a"); ttl < 49 || ttl > 50 {
+		t.Fatalf("after ExpireAt ttl %d", ttl)
+	}
+	if lc.Expire("none", 20) || lc.Persist("none") || lc.Touch("none") {
+		t.Fatal("operations on missing key should return false")
+	}
+
+	lc.Persist("a")
+	if _, ttl, exist := lc.Get("a"); !exist || ttl != ttltype.Persist {
+		t.Fatalf("after Persist ttl %d", ttl)
+	}
+	lc.Set("a", p, ttltype.Keep)
+	if ttl, _ := lc.TTL("a"); ttl != ttltype.Persist {
+		t.Fatalf("Set Keep on persisted key ttl %d", ttl)
+	}
+
+	lc.Set("b", p, 3)
+	time.Sleep(2 * time.Second)
+	lc.Touch("b")
+	if ttl, _ := lc.TTL("b"); ttl != 3 {
+		t.Fatalf("after Touch ttl %d, want 3", ttl)
+	}
+
+	lc.Expire("b", 0)
+	if _, exist := lc.TTL("b"); exist {
+		t.Fatal("Expire 0 should delete the key")
+	}
+}
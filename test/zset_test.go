@@ -0,0 +1,75 @@
+package test
+
+import (
+	localcache "github.com/daqnext/go-fast-cache"
+	"github.com/daqnext/go-fast-cache/zset"
+	"math"
+	"testing"
+)
+
+func Test_ZSet(t *testing.T) {
+	z := zset.New()
+	z.ZAdd("alice", 30)
+	z.ZAdd("bob", 10)
+	z.ZAdd("carol", 20)
+	z.ZAdd("dave", 20)
+	if z.ZAdd("bob", 15) {
+		t.Fatal("ZAdd existing member should return false")
+	}
+
+	want := []string{"bob", "carol", "dave", "alice"}
+	for i, e := range z.ZRange(0, -1) {
+		if e.Member != want[i] {
+			t.Fatalf("ZRange[%d] = %s, want %s", i, e.Member, want[i])
+		}
+	}
+	if rank, _ := z.ZRank("dave"); rank != 2 {
+		t.Fatalf("ZRank dave = %d, want 2", rank)
+	}
+	if rank, _ := z.ZRevRank("dave"); rank != 1 {
+		t.Fatalf("ZRevRank dave = %d, want 1", rank)
+	}
+	if top := z.ZRevRange(0, 0); len(top) != 1 || top[0].Member != "alice" {
+		t.Fatalf("ZRevRange top = %v", top)
+	}
+	if s, err := z.ZIncrBy("bob", 100); err != nil || s != 115 {
+		t.Fatalf("ZIncrBy = %v %v, want 115", s, err)
+	}
+	if r := z.ZRangeByScore(20, 30, 0, -1); len(r) != 3 {
+		t.Fatalf("ZRangeByScore = %v", r)
+	}
+	z.ZRem("carol")
+	if _, exist := z.ZScore("carol"); exist || z.ZCard() != 3 {
+		t.Fatal("ZRem failed")
+	}
+
+	//store as a cache value
+	lc := localcache.New(log)
+	lc.Set("leaderboard", z, 300)
+	v, _, _ := lc.Get("leaderboard")
+	v.(*zset.ZSet).ZIncrBy("erin", 1)
+	if z.ZCard() != 4 {
+		t.Fatal("cached ZSet should be shared")
+	}
+}
+
+func Test_ZSetNaN(t *testing.T) {
+	z := zset.New()
+	if z.ZAdd("x", math.NaN()) || z.ZCard() != 0 {
+		t.Fatal("ZAdd NaN should be rejected")
+	}
+	z.ZAdd("x", 1)
+	if z.ZAdd("x", math.NaN()) {
+		t.Fatal("ZAdd NaN should be rejected")
+	}
+	z.ZAdd("inf", math.Inf(1))
+	if _, err := z.ZIncrBy("inf", math.Inf(-1)); err != zset.ErrNaN {
+		t.Fatalf("ZIncrBy +Inf-Inf err %v, want ErrNaN", err)
+	}
+	if s, _ := z.ZScore("x"); s != 1 || z.ZCard() != 2 {
+		t.Fatalf("set changed by NaN: x=%v card=%d", s, z.ZCard())
+	}
+	if r := z.ZRange(0, -1); len(r) != 2 || r[0].Member != "x" || r[1].Score != math.Inf(1) {
+		t.Fatalf("ZRange = %v", r)
+	}
+}
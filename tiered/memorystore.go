@@ -0,0 +1,59 @@
+package tiered
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryItem struct {
+	value    []byte
+	expireAt time.Time
+}
+
+// MemoryStore is an in-memory RemoteStore for tests, it counts the calls it received
+type MemoryStore struct {
+	lock  sync.Mutex
+	items map[string]memoryItem
+
+	Gets    int
+	Sets    int
+	Deletes int
+}
+
+// NewMemoryStore makes a new MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		items: make(map[string]memoryItem),
+	}
+}
+
+func (m *MemoryStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.Gets++
+	item, exist := m.items[key]
+	if !exist || !time.Now().Before(item.expireAt) {
+		return nil, false, nil
+	}
+	return item.value, true, nil
+}
+
+func (m *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.Sets++
+	m.items[key] = memoryItem{
+		value:    value,
+		expireAt: time.Now().Add(ttl),
+	}
+	return nil
+}
+
+func (m *MemoryStore) Delete(ctx context.Context, key string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.Deletes++
+	delete(m.items, key)
+	return nil
+}
@@ -0,0 +1,106 @@
+package tiered
+
+import (
+	"context"
+	"errors"
+	localcache "github.com/daqnext/go-fast-cache"
+	"time"
+)
+
+const (
+	DefaultLocalTTLSecond = 10
+)
+
+// ErrInvalidTTL is returned by Set for a ttl which is not positive, ttltype.Keep included as the ttl left in the remote store is unknown
+var ErrInvalidTTL = errors.New("tiered: ttl must be positive")
+
+// RemoteStore is the second tier behind LocalCache, like redis or memcached
+type RemoteStore interface {
+	// Get returns exist false without error if key not exist
+	Get(ctx context.Context, key string) (value []byte, exist bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+type WriteMode int
+
+const (
+	// WriteThrough writes the remote store and the local cache
+	WriteThrough WriteMode = iota
+	// WriteAround writes the remote store and drops the local copy, the next Get populates it
+	WriteAround
+)
+
+// Config of TieredCache
+type Config struct {
+	// LocalTTLSecond is the max ttl of values kept in the local cache, default is DefaultLocalTTLSecond
+	LocalTTLSecond int64
+	WriteMode      WriteMode
+}
+
+// TieredCache consults the LocalCache first and falls back to the RemoteStore, values are []byte
+type TieredCache struct {
+	local  *localcache.LocalCache
+	remote RemoteStore
+	config Config
+}
+
+// New returns a TieredCache with local as L1 and remote as L2
+func New(local *localcache.LocalCache, remote RemoteStore, config Config) *TieredCache {
+	if config.LocalTTLSecond <= 0 {
+		config.LocalTTLSecond = DefaultLocalTTLSecond
+	}
+	return &TieredCache{
+		local:  local,
+		remote: remote,
+		config: config,
+	}
+}
+
+// Get returns the value from the local cache, or from the remote store and populates the local cache
+func (tc *TieredCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if value, _, exist := tc.local.Get(key); exist {
+		if b, ok := value.([]byte); ok {
+			return b, true, nil
+		}
+	}
+	value, exist, err := tc.remote.Get(ctx, key)
+	if err != nil || !exist {
+		return nil, false, err
+	}
+	tc.local.Set(key, value, tc.config.LocalTTLSecond)
+	return value, true, nil
+}
+
+// Set writes the value with ttlSecond to the remote store, and to the local cache by the WriteMode. Returns ErrInvalidTTL if ttlSecond is not positive
+func (tc *TieredCache) Set(ctx context.Context, key string, value []byte, ttlSecond int64) error {
+	if ttlSecond <= 0 {
+		return ErrInvalidTTL
+	}
+	if err := tc.remote.Set(ctx, key, value, time.Duration(ttlSecond)*time.Second); err != nil {
+		//the old local copy is stale
+		tc.local.Delete(key)
+		return err
+	}
+	if tc.config.WriteMode == WriteAround {
+		tc.local.Delete(key)
+		return nil
+	}
+	localTTL := ttlSecond
+	if localTTL > tc.config.LocalTTLSecond {
+		localTTL = tc.config.LocalTTLSecond
+	}
+	tc.local.Set(key, value, localTTL)
+	return nil
+}
+
+// Delete deletes key from both tiers
+func (tc *TieredCache) Delete(ctx context.Context, key string) error {
+	tc.local.Delete(key)
+	return tc.remote.Delete(ctx, key)
+}
+
+// Local returns the local tier
+func (tc *TieredCache) Local() *localcache.LocalCache {
+	return tc.local
+}
@@ -0,0 +1,92 @@
+package go_fast_cache
+
+import (
+	"github.com/daqnext/go-fast-cache/sortedset"
+	"github.com/daqnext/go-fast-cache/ttltype"
+	"math"
+	"time"
+)
+
+// persistScore never expires, and is evicted last when the key count is over limit
+const persistScore = math.MaxInt64
+
+// ttlLeft returns the ttl left of an unexpired element, ttltype.Persist if it never expires
+func ttlLeft(e sortedset.Element, nowTime int64) int64 {
+	if e.Score == persistScore {
+		return ttltype.Persist
+	}
+	return e.Score - nowTime
+}
+
+// TTL get ttl of a key with second, ttltype.Persist if the key never expires
+func (lc *LocalCache) TTL(key string) (int64, bool) {
+	e, exist := lc.s.Get(key)
+	if !exist {
+		return 0, false
+	}
+	nowTime := time.Now().Unix()
+	if e.Score <= nowTime {
+		return 0, false
+	}
+	return ttlLeft(e, nowTime), true
+}
+
+// Expire sets the ttl of an existing key without rewriting its value, ttlSecond <= 0 deletes the key. Returns false if key not exist
+func (lc *LocalCache) Expire(key string, ttlSecond int64) bool {
+	if ttlSecond <= 0 {
+		return lc.deleteExisting(key)
+	}
+	if ttlSecond > MaxTTLSecond {
+		ttlSecond = MaxTTLSecond
+	}
+	nowTime := time.Now().Unix()
+	return lc.s.Update(key, func(e *sortedset.Element) bool {
+		if e.Score <= nowTime {
+			return false
+		}
+		e.Score = nowTime + ttlSecond
+		e.TTL = ttlSecond
+		return true
+	})
+}
+
+// ExpireAt sets the expire time of an existing key, like Expire
+func (lc *LocalCache) ExpireAt(key string, t time.Time) bool {
+	return lc.Expire(key, t.Unix()-time.Now().Unix())
+}
+
+// Persist makes an existing key never expire, it is still evicted when the key count is over limit. Returns false if key not exist
+func (lc *LocalCache) Persist(key string) bool {
+	nowTime := time.Now().Unix()
+	return lc.s.Update(key, func(e *sortedset.Element) bool {
+		if e.Score <= nowTime {
+			return false
+		}
+		e.Score = persistScore
+		e.TTL = ttltype.Persist
+		return true
+	})
+}
+
+// Touch resets the ttl of an existing key to the ttl it was set with. Returns false if key not exist
+func (lc *LocalCache) Touch(key string) bool {
+	nowTime := time.Now().Unix()
+	return lc.s.Update(key, func(e *sortedset.Element) bool {
+		if e.Score <= nowTime {
+			return false
+		}
+		if e.Score != persistScore {
+			e.Score = nowTime + e.TTL
+		}
+		return true
+	})
+}
+
+// deleteExisting deletes an unexpired key, returns false if key not exist
+func (lc *LocalCache) deleteExisting(key string) bool {
+	if _, exist := lc.TTL(key); !exist {
+		return false
+	}
+	lc.delete(key)
+	return true
+}
@@ -2,4 +2,6 @@ package ttltype
 
 const (
 	Keep = int64(0)
+	// Persist is the ttl reported for keys which never expire
+	Persist = int64(-1)
 )
@@ -0,0 +1,180 @@
+package zset
+
+import (
+	"errors"
+	"math"
+	"sync"
+)
+
+// ErrNaN is returned by ZIncrBy when the score would be NaN, like Redis the set is unchanged
+var ErrNaN = errors.New("resulting score is not a number (NaN)")
+
+// ZSet is a Redis-like sorted set with float64 scores, members with the same score are ordered lexicographically.
+// ZSet is safe for concurrent use, so a *ZSet can be stored as a value in LocalCache
+type ZSet struct {
+	dict     map[string]float64
+	skiplist *skiplist
+	lock     sync.RWMutex
+}
+
+// New makes a new ZSet
+func New() *ZSet {
+	return &ZSet{
+		dict:     make(map[string]float64),
+		skiplist: makeSkiplist(),
+	}
+}
+
+// ZAdd puts member into set with score, returns true if member is new. A NaN score is rejected, it returns false and the set is unchanged
+func (z *ZSet) ZAdd(member string, score float64) bool {
+	if math.IsNaN(score) {
+		return false
+	}
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	return z.add(member, score)
+}
+
+func (z *ZSet) add(member string, score float64) bool {
+	oldScore, exist := z.dict[member]
+	if exist {
+		if oldScore == score {
+			return false
+		}
+		z.skiplist.remove(member, oldScore)
+	}
+	z.dict[member] = score
+	z.skiplist.insert(member, score)
+	return !exist
+}
+
+// ZRem removes member from set, returns false if member not exist
+func (z *ZSet) ZRem(member string) bool {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	score, exist := z.dict[member]
+	if !exist {
+		return false
+	}
+	delete(z.dict, member)
+	z.skiplist.remove(member, score)
+	return true
+}
+
+// ZScore returns the score of member
+func (z *ZSet) ZScore(member string) (float64, bool) {
+	z.lock.RLock()
+	defer z.lock.RUnlock()
+	score, exist := z.dict[member]
+	return score, exist
+}
+
+// ZIncrBy increments the score of member by increment, member not exist is added with score increment. Returns the new score, or ErrNaN if it would be NaN
+func (z *ZSet) ZIncrBy(member string, increment float64) (float64, error) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	score := z.dict[member] + increment
+	if math.IsNaN(score) {
+		return 0, ErrNaN
+	}
+	z.add(member, score)
+	return score, nil
+}
+
+// ZCard returns number of members in set
+func (z *ZSet) ZCard() int64 {
+	z.lock.RLock()
+	defer z.lock.RUnlock()
+	return z.skiplist.length
+}
+
+// ZRank returns the 0-based rank of member ordered by ascending score
+func (z *ZSet) ZRank(member string) (int64, bool) {
+	z.lock.RLock()
+	defer z.lock.RUnlock()
+	score, exist := z.dict[member]
+	if !exist {
+		return 0, false
+	}
+	return z.skiplist.getRank(member, score) - 1, true
+}
+
+// ZRevRank returns the 0-based rank of member ordered by descending score
+func (z *ZSet) ZRevRank(member string) (int64, bool) {
+	z.lock.RLock()
+	defer z.lock.RUnlock()
+	score, exist := z.dict[member]
+	if !exist {
+		return 0, false
+	}
+	return z.skiplist.length - z.skiplist.getRank(member, score), true
+}
+
+// ZRange returns members ranking within [start, stop] by ascending score, negative index counts from the end like Redis
+func (z *ZSet) ZRange(start int64, stop int64) []Element {
+	return z.rangeByRank(start, stop, false)
+}
+
+// ZRevRange returns members ranking within [start, stop] by descending score
+func (z *ZSet) ZRevRange(start int64, stop int64) []Element {
+	return z.rangeByRank(start, stop, true)
+}
+
+func (z *ZSet) rangeByRank(start int64, stop int64, desc bool) []Element {
+	z.lock.RLock()
+	defer z.lock.RUnlock()
+	length := z.skiplist.length
+	if start < 0 {
+		start += length
+	}
+	if stop < 0 {
+		stop += length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= length {
+		stop = length - 1
+	}
+	if start > stop {
+		return make([]Element, 0)
+	}
+
+	slice := make([]Element, 0, stop-start+1)
+	var node *node
+	if desc {
+		node = z.skiplist.getByRank(length - start)
+	} else {
+		node = z.skiplist.getByRank(start + 1)
+	}
+	for i := start; i <= stop && node != nil; i++ {
+		slice = append(slice, node.Element)
+		if desc {
+			node = node.backward
+		} else {
+			node = node.level[0].forward
+		}
+	}
+	return slice
+}
+
+// ZRangeByScore returns members which score within [min, max] by ascending score
+// param limit: <0 means no limit
+func (z *ZSet) ZRangeByScore(min float64, max float64, offset int64, limit int64) []Element {
+	z.lock.RLock()
+	defer z.lock.RUnlock()
+	slice := make([]Element, 0)
+	if limit == 0 || offset < 0 {
+		return slice
+	}
+	node := z.skiplist.getFirstInScoreRange(min, max)
+	for node != nil && offset > 0 {
+		node = node.level[0].forward
+		offset--
+	}
+	for node != nil && node.Score <= max && (limit < 0 || int64(len(slice)) < limit) {
+		slice = append(slice, node.Element)
+		node = node.level[0].forward
+	}
+	return slice
+}